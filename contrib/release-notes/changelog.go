@@ -0,0 +1,74 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// changelogMarkers returns the comment markers that delimit the
+// generated section for a version, which is what makes repeated runs
+// idempotent: an existing generated section for the same version is
+// replaced rather than duplicated.
+func changelogMarkers(version string) (string, string) {
+	return fmt.Sprintf("<!-- BEGIN generated release notes for %s -->", version),
+		fmt.Sprintf("<!-- END generated release notes for %s -->", version)
+}
+
+// updateChangelog inserts the rendered notes into the changelog file
+// under a "## <version>" heading. The heading is created at the top of
+// the file (below any initial document title) if it does not exist, and
+// a previously generated section for the same version is replaced in
+// place.
+func updateChangelog(path, version string, r *report) error {
+	buf := &bytes.Buffer{}
+	r.writeMarkdown(buf)
+	begin, end := changelogMarkers(version)
+	block := begin + "\n\n" + strings.TrimSpace(buf.String()) + "\n\n" + end
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	content := string(raw)
+
+	if i, j := strings.Index(content, begin), strings.Index(content, end); i >= 0 && j > i {
+		content = content[:i] + block + content[j+len(end):]
+		return ioutil.WriteFile(path, []byte(content), 0644)
+	}
+
+	heading := "## " + version
+	if i := strings.Index(content, heading); i >= 0 {
+		insert := i + len(heading)
+		content = content[:insert] + "\n\n" + block + content[insert:]
+		return ioutil.WriteFile(path, []byte(content), 0644)
+	}
+
+	section := heading + "\n\n" + block + "\n"
+	// Keep an initial "# ..." document title at the top.
+	if strings.HasPrefix(content, "# ") {
+		if i := strings.Index(content, "\n"); i >= 0 {
+			content = content[:i+1] + "\n" + section + content[i+1:]
+			return ioutil.WriteFile(path, []byte(content), 0644)
+		}
+	}
+	return ioutil.WriteFile(path, []byte(section+content), 0644)
+}
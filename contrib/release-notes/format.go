@@ -0,0 +1,391 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+)
+
+// report holds everything collected for the release so the output
+// formats can render it without re-querying GitHub.
+type report struct {
+	org, repo string
+	// subject describes the range the notes cover, e.g. a PR number
+	// span or a ref range.
+	subject string
+	// byComponent groups the main notes under component headings.
+	byComponent bool
+	// actionRequired entries describe breaking changes and are rendered
+	// prominently ahead of the other notes.
+	actionRequired []entry
+	entries        []entry
+	// newcomers holds the first in-range PR of each author new to the
+	// repository.
+	newcomers []pullRequest
+	missing   []pullRequest
+}
+
+// grouped buckets the entries by component, returning the component
+// names in sorted order with entries that have no component last under
+// an empty name.
+func (r *report) grouped() ([]string, map[string][]entry) {
+	groups := map[string][]entry{}
+	for _, e := range r.entries {
+		groups[e.component] = append(groups[e.component], e)
+	}
+	names := []string{}
+	for name := range groups {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := groups[""]; ok {
+		names = append(names, "")
+	}
+	return names, groups
+}
+
+// fixedIssueRef is one row of the fixed issues index: an issue and the
+// PRs in the release that fixed it.
+type fixedIssueRef struct {
+	issue fixedIssue
+	prs   []pullRequest
+}
+
+// fixedIssueIndex flattens the fixed issues of all entries into an
+// index sorted by issue number.
+func (r *report) fixedIssueIndex() []fixedIssueRef {
+	byNumber := map[int]*fixedIssueRef{}
+	numbers := []int{}
+	for _, entries := range [][]entry{r.actionRequired, r.entries} {
+		for _, e := range entries {
+			for _, f := range e.fixes {
+				ref, ok := byNumber[f.number]
+				if !ok {
+					ref = &fixedIssueRef{issue: f}
+					byNumber[f.number] = ref
+					numbers = append(numbers, f.number)
+				}
+				ref.prs = append(ref.prs, e.pr)
+			}
+		}
+	}
+	sort.Ints(numbers)
+	index := []fixedIssueRef{}
+	for _, number := range numbers {
+		index = append(index, *byNumber[number])
+	}
+	return index
+}
+
+// fixesText renders an entry's fixed-issue references for the plain
+// text output, e.g. ", fixes #123 #456".
+func fixesText(e entry) string {
+	if len(e.fixes) == 0 {
+		return ""
+	}
+	refs := ""
+	for _, f := range e.fixes {
+		refs += fmt.Sprintf(" #%d", f.number)
+	}
+	return ", fixes" + refs
+}
+
+// fixesMarkdown renders an entry's fixed-issue references as links.
+func fixesMarkdown(e entry) string {
+	if len(e.fixes) == 0 {
+		return ""
+	}
+	refs := ""
+	for _, f := range e.fixes {
+		refs += fmt.Sprintf(" [#%d](%s)", f.number, f.url)
+	}
+	return ", fixes" + refs
+}
+
+// write renders the report in the requested format.
+func (r *report) write(w io.Writer, format string) error {
+	switch format {
+	case "text":
+		r.writeText(w)
+	case "markdown":
+		r.writeMarkdown(w)
+	case "html":
+		r.writeHTML(w)
+	case "json":
+		return r.writeJSON(w)
+	default:
+		return fmt.Errorf("unknown format %q (expected text, markdown, html or json)", format)
+	}
+	return nil
+}
+
+func (r *report) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Release notes for %s/%s (%s):\n", r.org, r.repo, r.subject)
+	if len(r.actionRequired) > 0 {
+		fmt.Fprintf(w, "\nACTION REQUIRED:\n")
+		for _, e := range r.actionRequired {
+			fmt.Fprintf(w, "   * %s (#%d, @%s%s)\n", e.note, e.pr.Number, e.pr.User.Login, fixesText(e))
+		}
+		fmt.Fprintf(w, "\nOther notable changes:\n")
+	}
+	if r.byComponent {
+		names, groups := r.grouped()
+		for _, name := range names {
+			title := name
+			if title == "" {
+				title = "other"
+			}
+			fmt.Fprintf(w, "\n%s:\n", title)
+			for _, e := range groups[name] {
+				fmt.Fprintf(w, "   * %s (#%d, @%s%s)\n", e.note, e.pr.Number, e.pr.User.Login, fixesText(e))
+			}
+		}
+	} else {
+		for _, e := range r.entries {
+			fmt.Fprintf(w, "   * %s (#%d, @%s%s)\n", e.note, e.pr.Number, e.pr.User.Login, fixesText(e))
+		}
+	}
+	if index := r.fixedIssueIndex(); len(index) > 0 {
+		fmt.Fprintf(w, "\nFixed issues:\n")
+		for _, ref := range index {
+			fmt.Fprintf(w, "   * #%d: %s (", ref.issue.number, ref.issue.title)
+			for i, pr := range ref.prs {
+				if i > 0 {
+					fmt.Fprintf(w, ", ")
+				}
+				fmt.Fprintf(w, "#%d", pr.Number)
+			}
+			fmt.Fprintf(w, ")\n")
+		}
+	}
+	if len(r.newcomers) > 0 {
+		fmt.Fprintf(w, "\nNew contributors:\n")
+		for _, pr := range r.newcomers {
+			fmt.Fprintf(w, "   * @%s (#%d)\n", pr.User.Login, pr.Number)
+		}
+	}
+	if len(r.missing) > 0 {
+		fmt.Fprintf(w, "\nPRs that still need a release note:\n")
+		for _, pr := range r.missing {
+			fmt.Fprintf(w, "   * #%d: %s (@%s)\n", pr.Number, pr.Title, pr.User.Login)
+		}
+	}
+}
+
+// writeMarkdown produces publishable Markdown: every PR and author is
+// linked, and the list of PRs that still need a note is tucked into a
+// collapsible section so it does not clutter the published notes.
+func (r *report) writeMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "## Release notes for %s/%s (%s)\n\n", r.org, r.repo, r.subject)
+	if len(r.actionRequired) > 0 {
+		fmt.Fprintf(w, "### Action required\n\n")
+		for _, e := range r.actionRequired {
+			fmt.Fprintf(w, "* %s ([#%d](%s), [@%s](%s)%s)\n",
+				e.note, e.pr.Number, e.pr.HTMLURL, e.pr.User.Login, e.pr.User.HTMLURL, fixesMarkdown(e))
+		}
+		fmt.Fprintf(w, "\n### Other notable changes\n\n")
+	}
+	if r.byComponent {
+		names, groups := r.grouped()
+		for _, name := range names {
+			title := name
+			if title == "" {
+				title = "other"
+			}
+			fmt.Fprintf(w, "#### %s\n\n", title)
+			for _, e := range groups[name] {
+				fmt.Fprintf(w, "* %s ([#%d](%s), [@%s](%s)%s)\n",
+					e.note, e.pr.Number, e.pr.HTMLURL, e.pr.User.Login, e.pr.User.HTMLURL, fixesMarkdown(e))
+			}
+			fmt.Fprintf(w, "\n")
+		}
+	} else {
+		for _, e := range r.entries {
+			fmt.Fprintf(w, "* %s ([#%d](%s), [@%s](%s)%s)\n",
+				e.note, e.pr.Number, e.pr.HTMLURL, e.pr.User.Login, e.pr.User.HTMLURL, fixesMarkdown(e))
+		}
+	}
+	if index := r.fixedIssueIndex(); len(index) > 0 {
+		fmt.Fprintf(w, "\n### Fixed issues\n\n")
+		for _, ref := range index {
+			fmt.Fprintf(w, "* [#%d](%s): %s (", ref.issue.number, ref.issue.url, ref.issue.title)
+			for i, pr := range ref.prs {
+				if i > 0 {
+					fmt.Fprintf(w, ", ")
+				}
+				fmt.Fprintf(w, "[#%d](%s)", pr.Number, pr.HTMLURL)
+			}
+			fmt.Fprintf(w, ")\n")
+		}
+	}
+	if len(r.newcomers) > 0 {
+		fmt.Fprintf(w, "\n### New contributors\n\nThanks to the contributors whose first change landed in this release:\n\n")
+		for _, pr := range r.newcomers {
+			fmt.Fprintf(w, "* [@%s](%s) ([#%d](%s))\n", pr.User.Login, pr.User.HTMLURL, pr.Number, pr.HTMLURL)
+		}
+	}
+	if len(r.missing) > 0 {
+		fmt.Fprintf(w, "\n<details>\n<summary>PRs that still need a release note</summary>\n\n")
+		for _, pr := range r.missing {
+			fmt.Fprintf(w, "* [#%d](%s): %s ([@%s](%s))\n",
+				pr.Number, pr.HTMLURL, pr.Title, pr.User.Login, pr.User.HTMLURL)
+		}
+		fmt.Fprintf(w, "\n</details>\n")
+	}
+}
+
+// jsonEntry is the JSON shape of one pull request in the report. For
+// PRs still missing a release note only the PR fields are populated.
+type jsonEntry struct {
+	Number         int         `json:"number"`
+	Title          string      `json:"title"`
+	Author         string      `json:"author"`
+	URL            string      `json:"url"`
+	Labels         []string    `json:"labels,omitempty"`
+	Note           string      `json:"note,omitempty"`
+	Component      string      `json:"component,omitempty"`
+	ActionRequired bool        `json:"action_required,omitempty"`
+	Fixes          []jsonIssue `json:"fixes,omitempty"`
+}
+
+// jsonIssue is the JSON shape of an issue reference.
+type jsonIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	FixedBy []int  `json:"fixed_by,omitempty"`
+}
+
+// jsonReport is the JSON shape of the whole report.
+type jsonReport struct {
+	Organization    string      `json:"organization"`
+	Repository      string      `json:"repository"`
+	Subject         string      `json:"subject"`
+	Entries         []jsonEntry `json:"entries"`
+	FixedIssues     []jsonIssue `json:"fixed_issues,omitempty"`
+	NewContributors []string    `json:"new_contributors,omitempty"`
+	Missing         []jsonEntry `json:"missing,omitempty"`
+}
+
+func jsonEntryFor(e entry, actionRequired bool) jsonEntry {
+	out := jsonEntry{
+		Number:         e.pr.Number,
+		Title:          e.pr.Title,
+		Author:         e.pr.User.Login,
+		URL:            e.pr.HTMLURL,
+		Labels:         e.labels,
+		Note:           e.note,
+		Component:      e.component,
+		ActionRequired: actionRequired,
+	}
+	for _, f := range e.fixes {
+		out.Fixes = append(out.Fixes, jsonIssue{Number: f.number, Title: f.title, URL: f.url})
+	}
+	return out
+}
+
+// writeJSON emits the report as a single JSON document so downstream
+// tooling can consume the data without scraping one of the formatted
+// outputs.
+func (r *report) writeJSON(w io.Writer) error {
+	out := jsonReport{
+		Organization: r.org,
+		Repository:   r.repo,
+		Subject:      r.subject,
+		Entries:      []jsonEntry{},
+	}
+	for _, e := range r.actionRequired {
+		out.Entries = append(out.Entries, jsonEntryFor(e, true))
+	}
+	for _, e := range r.entries {
+		out.Entries = append(out.Entries, jsonEntryFor(e, false))
+	}
+	for _, ref := range r.fixedIssueIndex() {
+		fixed := jsonIssue{Number: ref.issue.number, Title: ref.issue.title, URL: ref.issue.url}
+		for _, pr := range ref.prs {
+			fixed.FixedBy = append(fixed.FixedBy, pr.Number)
+		}
+		out.FixedIssues = append(out.FixedIssues, fixed)
+	}
+	for _, pr := range r.newcomers {
+		out.NewContributors = append(out.NewContributors, pr.User.Login)
+	}
+	for _, pr := range r.missing {
+		out.Missing = append(out.Missing, jsonEntry{
+			Number: pr.Number,
+			Title:  pr.Title,
+			Author: pr.User.Login,
+			URL:    pr.HTMLURL,
+		})
+	}
+	raw, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", raw)
+	return err
+}
+
+func (r *report) writeHTML(w io.Writer) {
+	fmt.Fprintf(w, "<h2>Release notes for %s/%s (%s)</h2>\n<ul>\n",
+		html.EscapeString(r.org), html.EscapeString(r.repo), html.EscapeString(r.subject))
+	for _, e := range r.actionRequired {
+		fmt.Fprintf(w, "<li><strong>ACTION REQUIRED:</strong> %s (<a href=%q>#%d</a>, <a href=%q>@%s</a>)</li>\n",
+			html.EscapeString(e.note), e.pr.HTMLURL, e.pr.Number, e.pr.User.HTMLURL, html.EscapeString(e.pr.User.Login))
+	}
+	for _, e := range r.entries {
+		fmt.Fprintf(w, "<li>%s (<a href=%q>#%d</a>, <a href=%q>@%s</a>)</li>\n",
+			html.EscapeString(e.note), e.pr.HTMLURL, e.pr.Number, e.pr.User.HTMLURL, html.EscapeString(e.pr.User.Login))
+	}
+	fmt.Fprintf(w, "</ul>\n")
+	if index := r.fixedIssueIndex(); len(index) > 0 {
+		fmt.Fprintf(w, "<h3>Fixed issues</h3>\n<ul>\n")
+		for _, ref := range index {
+			fmt.Fprintf(w, "<li><a href=%q>#%d</a>: %s (", ref.issue.url, ref.issue.number, html.EscapeString(ref.issue.title))
+			for i, pr := range ref.prs {
+				if i > 0 {
+					fmt.Fprintf(w, ", ")
+				}
+				fmt.Fprintf(w, "<a href=%q>#%d</a>", pr.HTMLURL, pr.Number)
+			}
+			fmt.Fprintf(w, ")</li>\n")
+		}
+		fmt.Fprintf(w, "</ul>\n")
+	}
+	if len(r.newcomers) > 0 {
+		fmt.Fprintf(w, "<h3>New contributors</h3>\n<ul>\n")
+		for _, pr := range r.newcomers {
+			fmt.Fprintf(w, "<li><a href=%q>@%s</a> (<a href=%q>#%d</a>)</li>\n",
+				pr.User.HTMLURL, html.EscapeString(pr.User.Login), pr.HTMLURL, pr.Number)
+		}
+		fmt.Fprintf(w, "</ul>\n")
+	}
+	if len(r.missing) > 0 {
+		fmt.Fprintf(w, "<h3>PRs that still need a release note</h3>\n<ul>\n")
+		for _, pr := range r.missing {
+			fmt.Fprintf(w, "<li><a href=%q>#%d</a>: %s (<a href=%q>@%s</a>)</li>\n",
+				pr.HTMLURL, pr.Number, html.EscapeString(pr.Title), pr.User.HTMLURL, html.EscapeString(pr.User.Login))
+		}
+		fmt.Fprintf(w, "</ul>\n")
+	}
+}
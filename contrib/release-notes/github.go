@@ -0,0 +1,534 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const githubAPI = "https://api.github.com"
+
+const (
+	// fetchWorkers is how many requests are issued in parallel when
+	// fetching per-PR details.
+	fetchWorkers = 10
+	// rateLimitFloor is the number of remaining requests at which the
+	// client pauses until the rate limit window resets, leaving a little
+	// headroom for other users of the same token.
+	rateLimitFloor = 5
+	// rateLimitRetries is how often a single request is retried after
+	// an abuse or secondary rate limit response.
+	rateLimitRetries = 3
+)
+
+// githubClient is a minimal GitHub REST client. It implements only the
+// calls this tool needs, which saves vendoring a full client library.
+// It tracks the rate limit headers on every response so that concurrent
+// fetching paces itself instead of running the token dry.
+type githubClient struct {
+	token      string
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	remaining int
+	reset     time.Time
+}
+
+func newGithubClient(token string) *githubClient {
+	return &githubClient{
+		token:      token,
+		httpClient: http.DefaultClient,
+		remaining:  -1,
+	}
+}
+
+// waitForRateLimit blocks while the remaining request budget is at the
+// floor and the window has not reset yet.
+func (c *githubClient) waitForRateLimit() {
+	c.mu.Lock()
+	remaining, reset := c.remaining, c.reset
+	c.mu.Unlock()
+	if remaining < 0 || remaining > rateLimitFloor {
+		return
+	}
+	delay := reset.Sub(time.Now())
+	if delay <= 0 {
+		return
+	}
+	glog.Warningf("Only %d GitHub requests remaining, sleeping %v until the window resets", remaining, delay)
+	time.Sleep(delay + time.Second)
+}
+
+// noteRateLimit records the rate limit headers of a response.
+func (c *githubClient) noteRateLimit(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remaining = remaining
+	if seconds, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		c.reset = time.Unix(seconds, 0)
+	}
+}
+
+// rateLimited reports whether a 403 response is a primary or secondary
+// (abuse) rate limit rejection rather than a permission problem.
+func rateLimited(header http.Header, body []byte) bool {
+	if header.Get("Retry-After") != "" || header.Get("X-RateLimit-Remaining") == "0" {
+		return true
+	}
+	text := strings.ToLower(string(body))
+	return strings.Contains(text, "rate limit") || strings.Contains(text, "abuse")
+}
+
+// retryDelay picks how long to back off after a rate limit rejection.
+func (c *githubClient) retryDelay(header http.Header) time.Duration {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	c.mu.Lock()
+	reset := c.reset
+	c.mu.Unlock()
+	if delay := reset.Sub(time.Now()); delay > 0 {
+		return delay + time.Second
+	}
+	return 30 * time.Second
+}
+
+type githubUser struct {
+	Login   string `json:"login"`
+	HTMLURL string `json:"html_url"`
+}
+
+type pullRequest struct {
+	Number         int        `json:"number"`
+	Title          string     `json:"title"`
+	Body           string     `json:"body"`
+	HTMLURL        string     `json:"html_url"`
+	User           githubUser `json:"user"`
+	MergedAt       *time.Time `json:"merged_at"`
+	MergeCommitSHA string     `json:"merge_commit_sha"`
+}
+
+// get fetches path (relative to the API root) with the given query
+// parameters and unmarshals the JSON response into out. Rate limit
+// rejections are retried after the window opens again.
+func (c *githubClient) get(path string, params url.Values, out interface{}) error {
+	u := githubAPI + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	for attempt := 0; ; attempt++ {
+		c.waitForRateLimit()
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			return err
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "token "+c.token)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to query %s: %v", u, err)
+		}
+		raw, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read response from %s: %v", u, err)
+		}
+		c.noteRateLimit(resp.Header)
+		if resp.StatusCode == http.StatusForbidden && attempt < rateLimitRetries && rateLimited(resp.Header, raw) {
+			delay := c.retryDelay(resp.Header)
+			glog.Warningf("Rate limited by GitHub, retrying %s in %v", u, delay)
+			time.Sleep(delay)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("query to %s failed with status %d: %s", u, resp.StatusCode, raw)
+		}
+		if err := json.Unmarshal(raw, out); err != nil {
+			return fmt.Errorf("failed to unmarshal response from %s: %v", u, err)
+		}
+		return nil
+	}
+}
+
+type githubLabel struct {
+	Name string `json:"name"`
+}
+
+type milestone struct {
+	Title string `json:"title"`
+}
+
+type issue struct {
+	Number    int           `json:"number"`
+	Title     string        `json:"title"`
+	HTMLURL   string        `json:"html_url"`
+	Labels    []githubLabel `json:"labels"`
+	Milestone *milestone    `json:"milestone"`
+	// PullRequest is present when the "issue" is really the issue side
+	// of a pull request.
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
+
+// labelNames flattens the issue's labels.
+func (is *issue) labelNames() []string {
+	names := []string{}
+	for _, label := range is.Labels {
+		names = append(names, label.Name)
+	}
+	return names
+}
+
+// issue returns the issue side of a pull request, which is where labels
+// and the milestone live.
+func (c *githubClient) issue(org, repo string, number int) (*issue, error) {
+	is := &issue{}
+	if err := c.get(fmt.Sprintf("/repos/%s/%s/issues/%d", org, repo, number), nil, is); err != nil {
+		return nil, err
+	}
+	return is, nil
+}
+
+// issues fetches the issue side of every given PR with a worker pool,
+// which turns one round trip per PR into a handful of concurrent
+// batches. Failed fetches are logged and omitted from the result.
+func (c *githubClient) issues(org, repo string, prs []pullRequest) map[int]*issue {
+	jobs := make(chan int)
+	out := map[int]*issue{}
+	var outMu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < fetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for number := range jobs {
+				is, err := c.issue(org, repo, number)
+				if err != nil {
+					glog.Warningf("Failed to fetch issue details for #%d: %v", number, err)
+					continue
+				}
+				outMu.Lock()
+				out[number] = is
+				outMu.Unlock()
+			}
+		}()
+	}
+	for _, pr := range prs {
+		jobs <- pr.Number
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
+
+// issuesByNumber fetches the given issues with a worker pool. Failed
+// fetches are logged and omitted from the result, so a note referencing
+// a deleted issue does not sink the whole run.
+func (c *githubClient) issuesByNumber(org, repo string, numbers []int) map[int]*issue {
+	jobs := make(chan int)
+	out := map[int]*issue{}
+	var outMu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < fetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for number := range jobs {
+				is, err := c.issue(org, repo, number)
+				if err != nil {
+					glog.Warningf("Failed to fetch referenced issue #%d: %v", number, err)
+					continue
+				}
+				outMu.Lock()
+				out[number] = is
+				outMu.Unlock()
+			}
+		}()
+	}
+	for _, number := range numbers {
+		jobs <- number
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
+
+type prFile struct {
+	Filename string `json:"filename"`
+}
+
+// files lists the paths changed by a pull request.
+func (c *githubClient) files(org, repo string, number int) ([]string, error) {
+	paths := []string{}
+	for page := 1; ; page++ {
+		params := url.Values{
+			"per_page": {"100"},
+			"page":     {strconv.Itoa(page)},
+		}
+		batch := []prFile{}
+		if err := c.get(fmt.Sprintf("/repos/%s/%s/pulls/%d/files", org, repo, number), params, &batch); err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return paths, nil
+		}
+		for _, file := range batch {
+			paths = append(paths, file.Filename)
+		}
+	}
+}
+
+// filesForAll fetches the changed files of every given PR with a worker
+// pool. Failed fetches are logged and omitted from the result.
+func (c *githubClient) filesForAll(org, repo string, prs []pullRequest) map[int][]string {
+	jobs := make(chan int)
+	out := map[int][]string{}
+	var outMu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < fetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for number := range jobs {
+				paths, err := c.files(org, repo, number)
+				if err != nil {
+					glog.Warningf("Failed to fetch changed files for #%d: %v", number, err)
+					continue
+				}
+				outMu.Lock()
+				out[number] = paths
+				outMu.Unlock()
+			}
+		}()
+	}
+	for _, pr := range prs {
+		jobs <- pr.Number
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
+
+type release struct {
+	TagName string `json:"tag_name"`
+}
+
+type commitInfo struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+	} `json:"commit"`
+}
+
+// mergeMessageRE matches the subject of a GitHub merge commit.
+var mergeMessageRE = regexp.MustCompile(`Merge pull request #(\d+)`)
+
+// latestReleaseTag returns the tag name of the most recent GitHub
+// release of org/repo.
+func (c *githubClient) latestReleaseTag(org, repo string) (string, error) {
+	rel := release{}
+	if err := c.get(fmt.Sprintf("/repos/%s/%s/releases/latest", org, repo), nil, &rel); err != nil {
+		return "", err
+	}
+	if rel.TagName == "" {
+		return "", fmt.Errorf("%s/%s has no releases", org, repo)
+	}
+	return rel.TagName, nil
+}
+
+// commitForRef resolves a tag, branch or SHA to its commit.
+func (c *githubClient) commitForRef(org, repo, ref string) (*commitInfo, error) {
+	commit := &commitInfo{}
+	if err := c.get(fmt.Sprintf("/repos/%s/%s/commits/%s", org, repo, ref), nil, commit); err != nil {
+		return nil, err
+	}
+	return commit, nil
+}
+
+// pullForCommit maps a commit back to the pull request that merged it,
+// using the association GitHub records for the commit and falling back
+// to the merge commit message.
+func (c *githubClient) pullForCommit(org, repo string, commit *commitInfo) (int, error) {
+	prs := []pullRequest{}
+	if err := c.get(fmt.Sprintf("/repos/%s/%s/commits/%s/pulls", org, repo, commit.SHA), nil, &prs); err == nil && len(prs) > 0 {
+		return prs[0].Number, nil
+	}
+	if matches := mergeMessageRE.FindStringSubmatch(commit.Commit.Message); matches != nil {
+		return strconv.Atoi(matches[1])
+	}
+	return 0, fmt.Errorf("no pull request found for commit %s", commit.SHA)
+}
+
+type comparison struct {
+	TotalCommits int          `json:"total_commits"`
+	Commits      []commitInfo `json:"commits"`
+}
+
+// compare lists the commits reachable from head but not base, e.g. the
+// commits that make up a release.
+func (c *githubClient) compare(org, repo, base, head string) ([]commitInfo, error) {
+	comp := comparison{}
+	if err := c.get(fmt.Sprintf("/repos/%s/%s/compare/%s...%s", org, repo, base, head), nil, &comp); err != nil {
+		return nil, err
+	}
+	if comp.TotalCommits > len(comp.Commits) {
+		return nil, fmt.Errorf("compare %s...%s has %d commits but the API returned only %d; use a smaller range",
+			base, head, comp.TotalCommits, len(comp.Commits))
+	}
+	return comp.Commits, nil
+}
+
+// pull fetches a single pull request.
+func (c *githubClient) pull(org, repo string, number int) (*pullRequest, error) {
+	pr := &pullRequest{}
+	if err := c.get(fmt.Sprintf("/repos/%s/%s/pulls/%d", org, repo, number), nil, pr); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// mergedPullsInRange returns the pull requests whose merge commits lie
+// between the two refs. Unlike mergedPullsBetween this follows the
+// actual git history, so it gives the right answer on release branches
+// where cherry-picked merges interleave with unrelated master work.
+func (c *githubClient) mergedPullsInRange(org, repo, base, head string) ([]pullRequest, error) {
+	commits, err := c.compare(org, repo, base, head)
+	if err != nil {
+		return nil, err
+	}
+	numbers := []int{}
+	seen := map[int]bool{}
+	for _, commit := range commits {
+		matches := mergeMessageRE.FindStringSubmatch(commit.Commit.Message)
+		if matches == nil {
+			continue
+		}
+		number, err := strconv.Atoi(matches[1])
+		if err != nil || seen[number] {
+			continue
+		}
+		seen[number] = true
+		numbers = append(numbers, number)
+	}
+	// Fetch the PRs concurrently, preserving commit order.
+	fetched := make([]*pullRequest, len(numbers))
+	errs := make([]error, len(numbers))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < fetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fetched[i], errs[i] = c.pull(org, repo, numbers[i])
+			}
+		}()
+	}
+	for i := range numbers {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	prs := []pullRequest{}
+	for i, pr := range fetched {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if pr.MergedAt != nil {
+			prs = append(prs, *pr)
+		}
+	}
+	return prs, nil
+}
+
+// authorsBefore pages back through the repository's closed pull
+// requests and returns the authors of every PR merged before the given
+// number. This walks the whole history, so callers should cache the
+// result.
+func (c *githubClient) authorsBefore(org, repo string, before int) (map[string]bool, error) {
+	authors := map[string]bool{}
+	for page := 1; ; page++ {
+		params := url.Values{
+			"state":     {"closed"},
+			"sort":      {"created"},
+			"direction": {"desc"},
+			"per_page":  {"100"},
+			"page":      {strconv.Itoa(page)},
+		}
+		batch := []pullRequest{}
+		if err := c.get(fmt.Sprintf("/repos/%s/%s/pulls", org, repo), params, &batch); err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return authors, nil
+		}
+		for _, pr := range batch {
+			if pr.Number >= before || pr.MergedAt == nil {
+				continue
+			}
+			authors[pr.User.Login] = true
+		}
+	}
+}
+
+// mergedPullsBetween returns the pull requests merged into org/repo
+// whose numbers are in (last, current]. Closed pull requests are listed
+// newest first, so the listing stops at the first number at or below
+// last.
+func (c *githubClient) mergedPullsBetween(org, repo string, last, current int) ([]pullRequest, error) {
+	prs := []pullRequest{}
+	for page := 1; ; page++ {
+		params := url.Values{
+			"state":     {"closed"},
+			"sort":      {"created"},
+			"direction": {"desc"},
+			"per_page":  {"100"},
+			"page":      {strconv.Itoa(page)},
+		}
+		batch := []pullRequest{}
+		if err := c.get(fmt.Sprintf("/repos/%s/%s/pulls", org, repo), params, &batch); err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			return prs, nil
+		}
+		for _, pr := range batch {
+			if pr.Number <= last {
+				return prs, nil
+			}
+			if pr.Number > current || pr.MergedAt == nil {
+				continue
+			}
+			prs = append(prs, pr)
+		}
+	}
+}
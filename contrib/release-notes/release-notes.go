@@ -0,0 +1,476 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+release-notes generates release notes from the pull requests merged
+between two releases, identified by the PR numbers that cut them:
+
+    release-notes --last-release-pr=12345 --current-release-pr=23456 --api-token=$TOKEN
+
+Rather than dumping raw PR titles, each PR body is scanned for a
+
+    ```release-note
+    ...
+    ```
+
+section and only those user-facing entries are emitted. A note of NONE
+marks a PR as intentionally having no user-facing impact; merged PRs
+with neither a note nor NONE are listed separately so the release
+manager can chase the authors.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+var (
+	lastReleasePR    = flag.Int("last-release-pr", 0, "Number of the PR that cut the previous release; detected from the latest GitHub release when unset")
+	currentReleasePR = flag.Int("current-release-pr", 0, "Number of the PR that cuts the release being documented")
+	apiToken         = flag.String("api-token", "", "GitHub API token; see https://github.com/settings/tokens")
+	organization     = flag.String("organization", "kubernetes", "GitHub organization the repository belongs to")
+	repository       = flag.String("repository", "kubernetes", "GitHub repository to generate notes for")
+	format           = flag.String("format", "markdown", "Output format: text, markdown, html or json (structured entries for downstream tooling)")
+	byComponent      = flag.Bool("by-component", false, "Group the notes by the top-level component each PR mostly touched, e.g. pkg/kubelet")
+	newContributors  = flag.Bool("new-contributors", false, "Include a section crediting authors whose first merged PR is in this release")
+	authorCache      = flag.String("author-cache", "", "File caching the authors seen before the release range, to avoid re-paginating the full PR history on every run")
+	refRange         = flag.String("range", "", "Generate notes for the commits between two tags or SHAs, e.g. v1.1.0..v1.1.3; overrides the PR number cutoffs")
+	milestoneFilter  = flag.String("milestone", "", "Only include PRs assigned to this GitHub milestone, e.g. v1.2")
+	changelog        = flag.String("changelog", "", "Update this CHANGELOG file in place instead of writing to stdout; requires --release-version")
+	releaseVersion   = flag.String("release-version", "", "Version heading the notes belong under when updating a changelog, e.g. v1.2.0")
+)
+
+// entry is one release note together with the pull request it came
+// from.
+type entry struct {
+	pr        pullRequest
+	note      string
+	labels    []string
+	component string
+	fixes     []fixedIssue
+}
+
+// fixedIssue is an issue a PR declared it fixes, resolved to its title
+// and URL.
+type fixedIssue struct {
+	number int
+	title  string
+	url    string
+}
+
+// fixesRE matches the "fixes #123" style keywords GitHub itself uses to
+// close issues when a PR merges.
+var fixesRE = regexp.MustCompile(`(?i)\b(?:fix(?:es|ed)?|close[sd]?|resolve[sd]?):?\s+#(\d+)`)
+
+// fixedIssueNumbers returns the numbers of the issues a PR declares it
+// fixes in its title or body.
+func fixedIssueNumbers(pr pullRequest) []int {
+	numbers := []int{}
+	seen := map[int]bool{}
+	for _, text := range []string{pr.Title, pr.Body} {
+		for _, match := range fixesRE.FindAllStringSubmatch(text, -1) {
+			number, err := strconv.Atoi(match[1])
+			if err != nil || number == pr.Number || seen[number] {
+				continue
+			}
+			seen[number] = true
+			numbers = append(numbers, number)
+		}
+	}
+	return numbers
+}
+
+// resolveFixedIssues looks up the issues the entries' PRs declare they
+// fix and attaches their titles and links, so the notes can answer "was
+// my bug fixed in this release" directly. References that turn out to
+// be pull requests rather than issues are dropped.
+func resolveFixedIssues(client *githubClient, groups ...[]entry) {
+	numbers := []int{}
+	seen := map[int]bool{}
+	for _, entries := range groups {
+		for i := range entries {
+			for _, number := range fixedIssueNumbers(entries[i].pr) {
+				if !seen[number] {
+					seen[number] = true
+					numbers = append(numbers, number)
+				}
+			}
+		}
+	}
+	if len(numbers) == 0 {
+		return
+	}
+	resolved := client.issuesByNumber(*organization, *repository, numbers)
+	for _, entries := range groups {
+		for i := range entries {
+			for _, number := range fixedIssueNumbers(entries[i].pr) {
+				is := resolved[number]
+				if is == nil || is.PullRequest != nil {
+					continue
+				}
+				entries[i].fixes = append(entries[i].fixes, fixedIssue{number: number, title: is.Title, url: is.HTMLURL})
+			}
+		}
+	}
+}
+
+// releaseNoteRE matches the fenced release-note section of a PR body.
+var releaseNoteRE = regexp.MustCompile("(?s)```release-note\\s*(.*?)```")
+
+// releaseNote extracts the release-note block from a PR body. It
+// returns the note text and whether a block was present; a note of
+// NONE is reported as present with empty text.
+func releaseNote(pr pullRequest) (string, bool) {
+	matches := releaseNoteRE.FindStringSubmatch(pr.Body)
+	if matches == nil {
+		return "", false
+	}
+	note := strings.Trim(strings.TrimSpace(matches[1]), "`")
+	if strings.EqualFold(note, "none") {
+		return "", true
+	}
+	return note, true
+}
+
+// authorCacheEntry is the on-disk format of the author cache: the set
+// of contributors whose first merged PR predates the given number.
+type authorCacheEntry struct {
+	Before  int      `json:"before"`
+	Authors []string `json:"authors"`
+}
+
+// knownAuthorsBefore returns the authors of every PR merged before the
+// given number. Computing the set walks the repository's entire PR
+// history, so with --author-cache the result is reused between runs for
+// the same boundary.
+func knownAuthorsBefore(client *githubClient, before int) (map[string]bool, error) {
+	if *authorCache != "" {
+		raw, err := ioutil.ReadFile(*authorCache)
+		if err == nil {
+			cached := authorCacheEntry{}
+			if err := json.Unmarshal(raw, &cached); err == nil && cached.Before == before {
+				glog.Infof("Using %d cached authors from %s", len(cached.Authors), *authorCache)
+				authors := map[string]bool{}
+				for _, author := range cached.Authors {
+					authors[author] = true
+				}
+				return authors, nil
+			}
+		}
+	}
+	glog.Infof("Collecting the authors of every PR merged before #%d; this pages through the full history", before)
+	authors, err := client.authorsBefore(*organization, *repository, before)
+	if err != nil {
+		return nil, err
+	}
+	if *authorCache != "" {
+		cached := authorCacheEntry{Before: before}
+		for author := range authors {
+			cached.Authors = append(cached.Authors, author)
+		}
+		sort.Strings(cached.Authors)
+		raw, err := json.Marshal(&cached)
+		if err == nil {
+			err = ioutil.WriteFile(*authorCache, raw, 0644)
+		}
+		if err != nil {
+			glog.Warningf("Failed to write author cache %s: %v", *authorCache, err)
+		}
+	}
+	return authors, nil
+}
+
+// byLogin sorts pull requests by author login.
+type byLogin []pullRequest
+
+func (b byLogin) Len() int           { return len(b) }
+func (b byLogin) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byLogin) Less(i, j int) bool { return b[i].User.Login < b[j].User.Login }
+
+// findNewContributors returns the first in-range PR of every author who
+// had no merged PR before the range.
+func findNewContributors(client *githubClient, prs []pullRequest) []pullRequest {
+	if len(prs) == 0 {
+		return nil
+	}
+	boundary := prs[0].Number
+	for _, pr := range prs {
+		if pr.Number < boundary {
+			boundary = pr.Number
+		}
+	}
+	known, err := knownAuthorsBefore(client, boundary)
+	if err != nil {
+		glog.Warningf("Failed to collect prior authors, skipping the new contributors section: %v", err)
+		return nil
+	}
+	firstByAuthor := map[string]pullRequest{}
+	for _, pr := range prs {
+		if known[pr.User.Login] {
+			continue
+		}
+		if first, ok := firstByAuthor[pr.User.Login]; !ok || pr.Number < first.Number {
+			firstByAuthor[pr.User.Login] = pr
+		}
+	}
+	newcomers := []pullRequest{}
+	for _, pr := range firstByAuthor {
+		newcomers = append(newcomers, pr)
+	}
+	sort.Sort(byLogin(newcomers))
+	return newcomers
+}
+
+// componentDirs are the top-level directories whose second path element
+// is the interesting component, e.g. pkg/kubelet rather than just pkg.
+var componentDirs = map[string]bool{
+	"cluster": true,
+	"cmd":     true,
+	"contrib": true,
+	"docs":    true,
+	"plugin":  true,
+	"pkg":     true,
+	"test":    true,
+}
+
+// fileComponent maps a changed file to the component that owns it.
+func fileComponent(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) >= 3 && parts[0] == "plugin" && parts[1] == "pkg" {
+		return strings.Join(parts[:3], "/")
+	}
+	if len(parts) >= 2 && componentDirs[parts[0]] {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+// prComponent picks the component that owns the most files the PR
+// changed, breaking ties by name so the choice is deterministic.
+func prComponent(files []string) string {
+	counts := map[string]int{}
+	for _, file := range files {
+		counts[fileComponent(file)]++
+	}
+	best := ""
+	for component, count := range counts {
+		if count > counts[best] || (count == counts[best] && (best == "" || component < best)) {
+			best = component
+		}
+	}
+	return best
+}
+
+// actionRequiredRE matches an explicit action-required marker inside a
+// release-note block.
+var actionRequiredRE = regexp.MustCompile(`(?i)action required`)
+
+// isActionRequired reports whether an entry describes a breaking change
+// the reader must act on, either via PR labels or via a marker in the
+// note itself.
+func isActionRequired(e entry) bool {
+	for _, label := range e.labels {
+		switch {
+		case strings.Contains(label, "breaking"),
+			label == "release-note-action-required",
+			label == "action-required":
+			return true
+		}
+	}
+	return actionRequiredRE.MatchString(e.note)
+}
+
+// cherryPickRE matches references to the PR a cherry-pick was taken
+// from, as produced by hack/cherry_pick_pull.sh ("Automated cherry pick
+// of #12345") or written by hand.
+var cherryPickRE = regexp.MustCompile(`[Cc]herry[- ]pick of #(\d+)`)
+
+// originalPull returns the number of the PR a cherry-pick PR was taken
+// from, or zero if the PR does not look like a cherry-pick.
+func originalPull(pr pullRequest) int {
+	for _, text := range []string{pr.Title, pr.Body} {
+		if matches := cherryPickRE.FindStringSubmatch(text); matches != nil {
+			number, err := strconv.Atoi(matches[1])
+			if err == nil && number != pr.Number {
+				return number
+			}
+		}
+	}
+	return 0
+}
+
+// dedupeCherryPicks maps cherry-pick PRs back to the PRs they were
+// taken from, so a change that landed on master and was then picked to
+// a release branch is credited once, against the original.
+func dedupeCherryPicks(client *githubClient, prs []pullRequest) []pullRequest {
+	present := map[int]bool{}
+	for _, pr := range prs {
+		present[pr.Number] = true
+	}
+	credited := map[int]bool{}
+	out := []pullRequest{}
+	for _, pr := range prs {
+		original := originalPull(pr)
+		if original == 0 {
+			if !credited[pr.Number] {
+				credited[pr.Number] = true
+				out = append(out, pr)
+			}
+			continue
+		}
+		if present[original] || credited[original] {
+			glog.V(1).Infof("Dropping #%d, a cherry-pick of #%d which is already credited", pr.Number, original)
+			continue
+		}
+		originalPR, err := client.pull(*organization, *repository, original)
+		if err != nil {
+			glog.Warningf("Failed to fetch #%d (original of cherry-pick #%d), crediting the cherry-pick: %v", original, pr.Number, err)
+			credited[pr.Number] = true
+			out = append(out, pr)
+			continue
+		}
+		glog.V(1).Infof("Crediting #%d in place of cherry-pick #%d", original, pr.Number)
+		credited[original] = true
+		out = append(out, *originalPR)
+	}
+	return out
+}
+
+// collect gathers the merged PRs the notes cover, either from the git
+// range given with --range or from the PR number cutoffs, and returns
+// them with a description of the range.
+func collect(client *githubClient) ([]pullRequest, string) {
+	if *refRange != "" {
+		parts := strings.Split(*refRange, "..")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			glog.Fatalf("--range must be of the form <base>..<head>, e.g. v1.1.0..v1.1.3")
+		}
+		prs, err := client.mergedPullsInRange(*organization, *repository, parts[0], parts[1])
+		if err != nil {
+			glog.Fatalf("Failed to list PRs in range %s: %v", *refRange, err)
+		}
+		return prs, fmt.Sprintf("%s to %s", parts[0], parts[1])
+	}
+
+	if *currentReleasePR == 0 {
+		glog.Fatalf("Either --range or --current-release-pr is required")
+	}
+	if *lastReleasePR == 0 {
+		// Find the previous release boundary: take the latest GitHub
+		// release, resolve its tag to a commit, and map the commit back
+		// to the PR that merged it.
+		tag, err := client.latestReleaseTag(*organization, *repository)
+		if err != nil {
+			glog.Fatalf("Failed to find the latest release (use --last-release-pr to override): %v", err)
+		}
+		commit, err := client.commitForRef(*organization, *repository, tag)
+		if err != nil {
+			glog.Fatalf("Failed to resolve release tag %s: %v", tag, err)
+		}
+		pr, err := client.pullForCommit(*organization, *repository, commit)
+		if err != nil {
+			glog.Fatalf("Failed to map release tag %s to a PR (use --last-release-pr to override): %v", tag, err)
+		}
+		glog.Infof("Latest release %s was cut by PR #%d", tag, pr)
+		*lastReleasePR = pr
+	}
+	prs, err := client.mergedPullsBetween(*organization, *repository, *lastReleasePR, *currentReleasePR)
+	if err != nil {
+		glog.Fatalf("Failed to list merged PRs: %v", err)
+	}
+	return prs, fmt.Sprintf("PRs #%d to #%d", *lastReleasePR, *currentReleasePR)
+}
+
+func main() {
+	flag.Parse()
+
+	client := newGithubClient(*apiToken)
+	prs, subject := collect(client)
+	glog.Infof("Found %d merged PRs (%s)", len(prs), subject)
+	prs = dedupeCherryPicks(client, prs)
+
+	entries := []entry{}
+	actionRequired := []entry{}
+	missing := []pullRequest{}
+	issues := client.issues(*organization, *repository, prs)
+	files := map[int][]string{}
+	if *byComponent {
+		files = client.filesForAll(*organization, *repository, prs)
+	}
+	for _, pr := range prs {
+		is := issues[pr.Number]
+		if is == nil {
+			is = &issue{}
+		}
+		if *milestoneFilter != "" && (is.Milestone == nil || is.Milestone.Title != *milestoneFilter) {
+			glog.V(1).Infof("Skipping #%d, not in milestone %s", pr.Number, *milestoneFilter)
+			continue
+		}
+		note, found := releaseNote(pr)
+		if !found {
+			missing = append(missing, pr)
+			continue
+		}
+		if note == "" {
+			continue
+		}
+		e := entry{pr: pr, note: note, labels: is.labelNames(), component: prComponent(files[pr.Number])}
+		if isActionRequired(e) {
+			actionRequired = append(actionRequired, e)
+		} else {
+			entries = append(entries, e)
+		}
+	}
+
+	resolveFixedIssues(client, actionRequired, entries)
+
+	r := &report{
+		org:            *organization,
+		repo:           *repository,
+		subject:        subject,
+		byComponent:    *byComponent,
+		actionRequired: actionRequired,
+		entries:        entries,
+		missing:        missing,
+	}
+	if *newContributors {
+		r.newcomers = findNewContributors(client, prs)
+	}
+	if *changelog != "" {
+		if *releaseVersion == "" {
+			glog.Fatalf("--release-version is required with --changelog")
+		}
+		if err := updateChangelog(*changelog, *releaseVersion, r); err != nil {
+			glog.Fatalf("Failed to update %s: %v", *changelog, err)
+		}
+		glog.Infof("Updated %s for %s", *changelog, *releaseVersion)
+		return
+	}
+	if err := r.write(os.Stdout, *format); err != nil {
+		glog.Fatalf("Failed to write notes: %v", err)
+	}
+}
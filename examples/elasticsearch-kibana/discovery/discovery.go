@@ -0,0 +1,603 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+discovery is a peer-discovery sidecar for clustered stateful
+applications. It watches the endpoints of a service, renders the peer
+list through a template to a file, and optionally runs a reload command
+or pushes the peers to a local Elasticsearch instance when the set
+changes. The built-in template writes one peer IP per line, which is the
+unicast hosts format Elasticsearch expects, but with --template_file any
+peer-seeded configuration can be produced, e.g. a zoo.cfg server list:
+
+    {{range $i, $p := .Peers}}server.{{$i}}={{$p}}:2888:3888
+    {{end}}
+
+or a cassandra.yaml seed list using {{join .Peers ","}}.
+
+Topologies with dedicated master nodes split the cluster across several
+services; --service accepts a comma-separated list of them, each
+optionally prefixed with a role, e.g.
+
+    --service=master=es-master,data=es-data
+
+The peers of all services are watched simultaneously and merged, and the
+template additionally receives the peers grouped by role as .Roles, so a
+config that treats master and data nodes differently can be rendered
+with {{range $role, $ips := .Roles}}.
+
+It is intended to run inside the application's pod, so by default it
+configures its client from the service account token and the
+KUBERNETES_SERVICE_HOST environment variable that every pod is given.
+For development outside a cluster a kubeconfig file can be used instead
+with --kubeconfig.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/client/cache"
+	"k8s.io/kubernetes/pkg/client/leaderelection"
+	"k8s.io/kubernetes/pkg/client/record"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
+	"k8s.io/kubernetes/pkg/controller/framework"
+	"k8s.io/kubernetes/pkg/fields"
+	"k8s.io/kubernetes/pkg/labels"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/util/wait"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+var (
+	kubeconfig     = flag.String("kubeconfig", "", "Path to a kubeconfig file; if empty the in-cluster service account configuration is used")
+	outFile        = flag.String("out_file", "/elasticsearch/config/unicast_hosts.txt", "File maintained with the rendered peer list; empty disables the file")
+	templateFile   = flag.String("template_file", "", "Go template rendered with the peer list as .Peers; empty uses the built-in one-IP-per-line template")
+	reloadCommand  = flag.String("reload_command", "", "Command run through 'sh -c' after the output file has been rewritten, e.g. to signal the server to reload")
+	esURL          = flag.String("elasticsearch", "http://localhost:9200", "Local Elasticsearch instance to reconfigure via the settings API when peers change; empty disables reconfiguration")
+	debouncePeriod = flag.Duration("debounce", 5*time.Second, "Quiet period after a peer change before the new peer set is applied")
+
+	// The namespace, service and selector may also be set through the
+	// NAMESPACE, SERVICE and SELECTOR environment variables, which is
+	// more convenient in pod templates using the downward API.
+	namespace = flag.String("namespace", envOrDefault("NAMESPACE", api.NamespaceDefault), "Namespace the watched service runs in")
+	service   = flag.String("service", envOrDefault("SERVICE", "elasticsearch"), "Comma-separated services whose endpoints form the peer list, each optionally prefixed with a role as in master=es-master,data=es-data")
+	selector  = flag.String("selector", envOrDefault("SELECTOR", ""), "Label selector matching Endpoints objects to watch; used instead of --service when set")
+
+	leaderElect = flag.Bool("leader_elect", false, "Perform leader election so that only one discovery sidecar reconfigures the cluster; the others stand by until the leader fails")
+	lockObject  = flag.String("leader_elect_lock", "elasticsearch-discovery", "Name of the Endpoints object used as the leader election lock")
+
+	statusPort = flag.Int("status_port", 8081, "Port for the /healthz, /readiness and /metrics endpoints; zero disables them")
+)
+
+// resyncPeriod controls how often the informer re-lists the endpoints.
+const resyncPeriod = 30 * time.Second
+
+const (
+	// watchBackoffInitial is the delay after the first failed list or
+	// watch call; it doubles on each consecutive failure.
+	watchBackoffInitial = time.Second
+	// watchBackoffMax caps the backoff so recovery after a long
+	// apiserver outage is still prompt.
+	watchBackoffMax = 30 * time.Second
+)
+
+// watchStats records the health of the endpoints watch so that gaps in
+// coverage during apiserver restarts are visible rather than silent.
+type watchStats struct {
+	sync.Mutex
+	listFailures  int
+	watchFailures int
+	gaps          int
+	lastHealthy   time.Time
+
+	peerUpdates  int
+	peerRemovals int
+	peerCount    int
+	lastApplied  time.Time
+}
+
+var stats = &watchStats{lastHealthy: time.Now()}
+
+// resilientListWatch wraps a ListerWatcher so the sidecar survives
+// apiserver rolling restarts gracefully: failed calls back off
+// exponentially instead of hammering a restarting apiserver, the
+// resourceVersion each watch resumes from is logged, and gaps in watch
+// coverage are counted. Any events missed during a gap are recovered by
+// the informer's relist.
+type resilientListWatch struct {
+	lw      cache.ListerWatcher
+	backoff time.Duration
+}
+
+func (r *resilientListWatch) List(options api.ListOptions) (runtime.Object, error) {
+	obj, err := r.lw.List(options)
+	if err != nil {
+		stats.Lock()
+		stats.listFailures++
+		stats.Unlock()
+		r.delay("list", err)
+		return nil, err
+	}
+	r.recovered()
+	return obj, nil
+}
+
+func (r *resilientListWatch) Watch(options api.ListOptions) (watch.Interface, error) {
+	glog.V(2).Infof("Starting endpoints watch from resourceVersion %q", options.ResourceVersion)
+	w, err := r.lw.Watch(options)
+	if err != nil {
+		stats.Lock()
+		stats.watchFailures++
+		stats.Unlock()
+		r.delay("watch", err)
+		return nil, err
+	}
+	r.recovered()
+	return w, nil
+}
+
+// delay sleeps for the current backoff period and doubles it.
+func (r *resilientListWatch) delay(op string, err error) {
+	glog.Warningf("Endpoints %s failed, backing off %v: %v", op, r.backoff, err)
+	time.Sleep(r.backoff)
+	if r.backoff *= 2; r.backoff > watchBackoffMax {
+		r.backoff = watchBackoffMax
+	}
+}
+
+// recovered resets the backoff, and if the connection had been failing
+// it records the end of a watch gap.
+func (r *resilientListWatch) recovered() {
+	stats.Lock()
+	defer stats.Unlock()
+	if r.backoff > watchBackoffInitial {
+		stats.gaps++
+		glog.Warningf("Watch re-established after a gap of %v (%d gaps so far); missed changes will be picked up by the relist",
+			time.Since(stats.lastHealthy), stats.gaps)
+	}
+	r.backoff = watchBackoffInitial
+	stats.lastHealthy = time.Now()
+}
+
+func envOrDefault(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// serveStatus exposes liveness and readiness probes for the kubelet and
+// a small set of Prometheus-style counters. /healthz reports whether
+// the process is alive; /readiness additionally requires a recent
+// successful list or watch against the apiserver and at least one
+// applied peer set, so a sidecar that has lost contact or never
+// resolved any peers reports not ready.
+func serveStatus() {
+	http.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+	http.HandleFunc("/readiness", func(w http.ResponseWriter, r *http.Request) {
+		stats.Lock()
+		healthy := time.Since(stats.lastHealthy)
+		applied := !stats.lastApplied.IsZero()
+		stats.Unlock()
+		if healthy > 2*resyncPeriod {
+			http.Error(w, fmt.Sprintf("no successful apiserver contact for %v", healthy), http.StatusServiceUnavailable)
+			return
+		}
+		if !applied {
+			http.Error(w, "no peer set applied yet", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		stats.Lock()
+		defer stats.Unlock()
+		fmt.Fprintf(w, "# TYPE elasticsearch_discovery_peer_updates_total counter\n")
+		fmt.Fprintf(w, "elasticsearch_discovery_peer_updates_total %d\n", stats.peerUpdates)
+		fmt.Fprintf(w, "# TYPE elasticsearch_discovery_peer_removals_total counter\n")
+		fmt.Fprintf(w, "elasticsearch_discovery_peer_removals_total %d\n", stats.peerRemovals)
+		fmt.Fprintf(w, "# TYPE elasticsearch_discovery_list_failures_total counter\n")
+		fmt.Fprintf(w, "elasticsearch_discovery_list_failures_total %d\n", stats.listFailures)
+		fmt.Fprintf(w, "# TYPE elasticsearch_discovery_watch_failures_total counter\n")
+		fmt.Fprintf(w, "elasticsearch_discovery_watch_failures_total %d\n", stats.watchFailures)
+		fmt.Fprintf(w, "# TYPE elasticsearch_discovery_watch_gaps_total counter\n")
+		fmt.Fprintf(w, "elasticsearch_discovery_watch_gaps_total %d\n", stats.gaps)
+		fmt.Fprintf(w, "# TYPE elasticsearch_discovery_peers gauge\n")
+		fmt.Fprintf(w, "elasticsearch_discovery_peers %d\n", stats.peerCount)
+	})
+	glog.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *statusPort), nil))
+}
+
+// makeClient constructs a client from the service account mounted into
+// the pod, falling back to a kubeconfig file when one is given.
+func makeClient() (*client.Client, error) {
+	if *kubeconfig == "" {
+		c, err := client.NewInCluster()
+		if err != nil {
+			return nil, fmt.Errorf("failed to make in-cluster client: %v", err)
+		}
+		return c, nil
+	}
+	settings, err := clientcmd.LoadFromFile(*kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("error loading kubeconfig %s: %v", *kubeconfig, err)
+	}
+	config, err := clientcmd.NewDefaultClientConfig(*settings, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct config: %v", err)
+	}
+	return client.New(config)
+}
+
+// peerAddresses flattens the ready addresses of an Endpoints object.
+// Addresses that are not ready are excluded by the endpoints controller
+// itself, so no special-casing of unready containers or pods without
+// IPs is needed here.
+func peerAddresses(obj interface{}) []string {
+	endpoints, ok := obj.(*api.Endpoints)
+	if !ok {
+		return nil
+	}
+	addrs := []string{}
+	for _, ss := range endpoints.Subsets {
+		for _, addr := range ss.Addresses {
+			addrs = append(addrs, addr.IP)
+		}
+	}
+	return addrs
+}
+
+// defaultTemplate is the built-in unicast hosts format: one peer IP per
+// line.
+const defaultTemplate = "{{range .Peers}}{{.}}\n{{end}}"
+
+// peerList is the merged peer set handed to the template: all peer IPs
+// in Peers, and the same IPs grouped by role in Roles for templates that
+// distinguish e.g. master-eligible from data nodes.
+type peerList struct {
+	Peers []string
+	Roles map[string][]string
+}
+
+// parseServices splits the --service flag into service-name-to-role
+// pairs. Entries are either a bare service name or role=name; a bare
+// name uses the service name itself as the role.
+func parseServices(spec string) (map[string]string, error) {
+	roles := map[string]string{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		role, name := entry, entry
+		if eq := strings.Index(entry, "="); eq >= 0 {
+			role, name = entry[:eq], entry[eq+1:]
+		}
+		if name == "" || role == "" {
+			return nil, fmt.Errorf("malformed service entry %q", entry)
+		}
+		if _, dup := roles[name]; dup {
+			return nil, fmt.Errorf("service %q listed twice", name)
+		}
+		roles[name] = role
+	}
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("no services given")
+	}
+	return roles, nil
+}
+
+// peerTemplate parses the configured template once. Beyond the standard
+// template functions a "join" helper is provided since most seed-list
+// formats are comma separated.
+func peerTemplate() (*template.Template, error) {
+	text := defaultTemplate
+	if *templateFile != "" {
+		raw, err := ioutil.ReadFile(*templateFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template %s: %v", *templateFile, err)
+		}
+		text = string(raw)
+	}
+	return template.New("peers").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(text)
+}
+
+// writeOutFile atomically rewrites the output file with the rendered
+// peer list.
+func writeOutFile(tmpl *template.Template, peers peerList) error {
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, peers); err != nil {
+		return fmt.Errorf("error rendering template: %v", err)
+	}
+	tmp := *outFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, *outFile)
+}
+
+// reload runs the configured reload command, e.g. to have the server
+// re-read the file just written.
+func reload() error {
+	out, err := exec.Command("sh", "-c", *reloadCommand).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return nil
+}
+
+// reconfigureES pushes the peer list to the local Elasticsearch
+// instance through the cluster settings API so that discovery picks up
+// the change without a restart.
+func reconfigureES(peers []string) error {
+	settings := map[string]interface{}{
+		"transient": map[string]interface{}{
+			"discovery.zen.ping.unicast.hosts": strings.Join(peers, ","),
+		},
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT", strings.TrimRight(*esURL, "/")+"/_cluster/settings", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("settings update failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// applyPeers makes the current peer set effective.
+func applyPeers(tmpl *template.Template, peers peerList) {
+	sort.Strings(peers.Peers)
+	for _, ips := range peers.Roles {
+		sort.Strings(ips)
+	}
+	glog.Infof("Applying peer set %v", peers.Peers)
+	stats.Lock()
+	stats.peerCount = len(peers.Peers)
+	stats.lastApplied = time.Now()
+	stats.Unlock()
+	if *outFile != "" {
+		if err := writeOutFile(tmpl, peers); err != nil {
+			glog.Errorf("Failed to write %s: %v", *outFile, err)
+		} else if *reloadCommand != "" {
+			if err := reload(); err != nil {
+				glog.Errorf("Reload command failed: %v", err)
+			}
+		}
+	}
+	if *esURL != "" {
+		if err := reconfigureES(peers.Peers); err != nil {
+			glog.Errorf("Failed to reconfigure Elasticsearch: %v", err)
+		}
+	}
+}
+
+func main() {
+	flag.Parse()
+	glog.Info("Elasticsearch discovery sidecar starting")
+
+	c, err := makeClient()
+	if err != nil {
+		glog.Fatalf("Failed to make client: %v", err)
+	}
+
+	if *statusPort != 0 {
+		go serveStatus()
+	}
+
+	if !*leaderElect {
+		run(c, wait.NeverStop)
+		return
+	}
+
+	// Every Elasticsearch pod runs a copy of this sidecar, but the
+	// cluster settings they push are cluster-wide, so with --leader_elect
+	// only one copy is active at a time. The lock is an annotation on a
+	// dedicated Endpoints object, the same mechanism the controller
+	// manager and scheduler use.
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(glog.Infof)
+	eventBroadcaster.StartRecordingToSink(c.Events(*namespace))
+	recorder := eventBroadcaster.NewRecorder(api.EventSource{Component: "elasticsearch-discovery"})
+
+	id, err := os.Hostname()
+	if err != nil {
+		glog.Fatalf("Failed to get hostname: %v", err)
+	}
+
+	leaderelection.RunOrDie(leaderelection.LeaderElectionConfig{
+		EndpointsMeta: api.ObjectMeta{
+			Namespace: *namespace,
+			Name:      *lockObject,
+		},
+		Client:        c,
+		Identity:      id,
+		EventRecorder: recorder,
+		LeaseDuration: leaderelection.DefaultLeaseDuration,
+		RenewDeadline: leaderelection.DefaultRenewDeadline,
+		RetryPeriod:   leaderelection.DefaultRetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(stop <-chan struct{}) {
+				run(c, stop)
+			},
+			OnStoppedLeading: func() {
+				// Exit rather than limp along; the kubelet restarts the
+				// container and it rejoins the election as a standby.
+				glog.Fatalf("Lost leadership, exiting")
+			},
+		},
+	})
+	panic("unreachable")
+}
+
+// run watches the endpoints and applies peer changes until stop is
+// closed. It is the body of the sidecar; with leader election it only
+// runs on the current leader.
+func run(c *client.Client, stop <-chan struct{}) {
+	tmpl, err := peerTemplate()
+	if err != nil {
+		glog.Fatalf("Failed to parse template: %v", err)
+	}
+
+	// Peer set changes are funnelled through a channel and debounced:
+	// endpoint churn during a rolling restart would otherwise trigger a
+	// reconfiguration for every single pod transition.
+	changes := make(chan peerList, 1)
+	go func() {
+		var pending peerList
+		var timer <-chan time.Time
+		for {
+			select {
+			case peers := <-changes:
+				pending = peers
+				timer = time.After(*debouncePeriod)
+			case <-timer:
+				timer = nil
+				applyPeers(tmpl, pending)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	// Watch the Endpoints of the Elasticsearch services rather than raw
+	// pods: the endpoints controller has already filtered the ready
+	// peers for us. Both --selector and a multi-service --service let
+	// several Endpoints objects contribute to the peer set (e.g.
+	// separate master and data services), so peers are tracked per
+	// object and merged. With --selector the roles are the names of the
+	// Endpoints objects; with --service they can be given explicitly.
+	roles := map[string]string{}
+	var lws []*cache.ListWatch
+	if *selector != "" {
+		labelSelector, err := labels.Parse(*selector)
+		if err != nil {
+			glog.Fatalf("Bad --selector %q: %v", *selector, err)
+		}
+		lws = append(lws, &cache.ListWatch{
+			ListFunc: func(options api.ListOptions) (runtime.Object, error) {
+				options.LabelSelector = labelSelector
+				return c.Endpoints(*namespace).List(options)
+			},
+			WatchFunc: func(options api.ListOptions) (watch.Interface, error) {
+				options.LabelSelector = labelSelector
+				return c.Endpoints(*namespace).Watch(options)
+			},
+		})
+	} else {
+		roles, err = parseServices(*service)
+		if err != nil {
+			glog.Fatalf("Bad --service %q: %v", *service, err)
+		}
+		for name := range roles {
+			lws = append(lws, cache.NewListWatchFromClient(c, "endpoints", *namespace,
+				fields.OneTermEqualSelector("metadata.name", name)))
+		}
+	}
+
+	var lock sync.Mutex
+	peersByObject := map[string][]string{}
+	merged := func() peerList {
+		all := peerList{Peers: []string{}, Roles: map[string][]string{}}
+		for name, peers := range peersByObject {
+			all.Peers = append(all.Peers, peers...)
+			role := roles[name]
+			if role == "" {
+				role = name
+			}
+			all.Roles[role] = append(all.Roles[role], peers...)
+		}
+		return all
+	}
+	update := func(obj interface{}) {
+		endpoints, ok := obj.(*api.Endpoints)
+		if !ok {
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		peers := peerAddresses(obj)
+		if reflect.DeepEqual(peersByObject[endpoints.Name], peers) {
+			return
+		}
+		peersByObject[endpoints.Name] = peers
+		stats.Lock()
+		stats.peerUpdates++
+		stats.Unlock()
+		changes <- merged()
+	}
+	remove := func(obj interface{}) {
+		endpoints, ok := obj.(*api.Endpoints)
+		if !ok {
+			return
+		}
+		glog.Warningf("Endpoints %s/%s deleted", *namespace, endpoints.Name)
+		lock.Lock()
+		defer lock.Unlock()
+		delete(peersByObject, endpoints.Name)
+		stats.Lock()
+		stats.peerRemovals++
+		stats.Unlock()
+		changes <- merged()
+	}
+
+	for _, lw := range lws {
+		_, controller := framework.NewInformer(
+			&resilientListWatch{lw: lw, backoff: watchBackoffInitial},
+			&api.Endpoints{},
+			resyncPeriod,
+			framework.ResourceEventHandlerFuncs{
+				AddFunc:    update,
+				UpdateFunc: func(old, cur interface{}) { update(cur) },
+				DeleteFunc: remove,
+			},
+		)
+		go controller.Run(stop)
+	}
+	<-stop
+}
@@ -0,0 +1,394 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/types"
+	"k8s.io/kubernetes/pkg/util"
+)
+
+// DecoratorConfig configures NewDecoratedCloud.
+type DecoratorConfig struct {
+	// CacheTTL bounds how long read results are served from cache
+	// instead of re-querying the cloud. Zero disables caching.
+	CacheTTL time.Duration
+	// QPS and Burst configure a token bucket rate limiter applied to
+	// every call that reaches the underlying cloud. Zero QPS disables
+	// rate limiting.
+	QPS   float32
+	Burst int
+}
+
+// OperationMetrics describes what the decorator observed for one
+// operation (e.g. "instances.NodeAddresses").
+type OperationMetrics struct {
+	// Calls counts invocations, including ones served from cache.
+	Calls int64
+	// CacheHits counts invocations served from cache.
+	CacheHits int64
+	// Errors counts invocations where the underlying cloud returned an
+	// error.
+	Errors int64
+}
+
+// DecoratedCloud wraps another cloud provider Interface with response
+// caching, rate limiting and call metrics. Cloud APIs throttle (and on
+// AWS can effectively lock out) accounts that query too aggressively;
+// decorating a provider bounds the query rate of every controller that
+// shares it, without each provider re-implementing the protection.
+// Mutating calls are never cached, and invalidate the cached reads they
+// make stale.
+type DecoratedCloud struct {
+	cloud   Interface
+	config  DecoratorConfig
+	limiter util.RateLimiter
+
+	mutex   sync.Mutex
+	cache   map[string]cacheEntry
+	metrics map[string]*OperationMetrics
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// NewDecoratedCloud wraps cloud according to config.
+func NewDecoratedCloud(cloud Interface, config DecoratorConfig) *DecoratedCloud {
+	d := &DecoratedCloud{
+		cloud:   cloud,
+		config:  config,
+		cache:   map[string]cacheEntry{},
+		metrics: map[string]*OperationMetrics{},
+	}
+	if config.QPS > 0 {
+		d.limiter = util.NewTokenBucketRateLimiter(config.QPS, config.Burst)
+	}
+	return d
+}
+
+// Metrics returns a snapshot of the per-operation metrics collected so
+// far.
+func (d *DecoratedCloud) Metrics() map[string]OperationMetrics {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	snapshot := map[string]OperationMetrics{}
+	for op, m := range d.metrics {
+		snapshot[op] = *m
+	}
+	return snapshot
+}
+
+func (d *DecoratedCloud) operation(op string) *OperationMetrics {
+	m, found := d.metrics[op]
+	if !found {
+		m = &OperationMetrics{}
+		d.metrics[op] = m
+	}
+	return m
+}
+
+// call invokes fn for the operation, applying rate limiting and
+// counting the call. Used for mutations and other uncacheable calls.
+func (d *DecoratedCloud) call(op string, fn func() (interface{}, error)) (interface{}, error) {
+	d.mutex.Lock()
+	d.operation(op).Calls++
+	d.mutex.Unlock()
+
+	if d.limiter != nil {
+		d.limiter.Accept()
+	}
+	value, err := fn()
+	if err != nil {
+		d.mutex.Lock()
+		d.operation(op).Errors++
+		d.mutex.Unlock()
+	}
+	return value, err
+}
+
+// cachedCall is like call, but serves repeated invocations with the
+// same key from cache while the previous result is still fresh. Errors
+// are never cached.
+func (d *DecoratedCloud) cachedCall(op, key string, fn func() (interface{}, error)) (interface{}, error) {
+	cacheKey := op + "/" + key
+
+	d.mutex.Lock()
+	d.operation(op).Calls++
+	if entry, found := d.cache[cacheKey]; found && time.Now().Before(entry.expires) {
+		d.operation(op).CacheHits++
+		d.mutex.Unlock()
+		return entry.value, nil
+	}
+	d.mutex.Unlock()
+
+	if d.limiter != nil {
+		d.limiter.Accept()
+	}
+	value, err := fn()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err != nil {
+		d.operation(op).Errors++
+		return value, err
+	}
+	if d.config.CacheTTL > 0 {
+		d.cache[cacheKey] = cacheEntry{value: value, expires: time.Now().Add(d.config.CacheTTL)}
+	}
+	return value, nil
+}
+
+// invalidate drops every cached result for the given operations.
+func (d *DecoratedCloud) invalidate(ops ...string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for cacheKey := range d.cache {
+		for _, op := range ops {
+			if len(cacheKey) > len(op) && cacheKey[:len(op)+1] == op+"/" {
+				delete(d.cache, cacheKey)
+				break
+			}
+		}
+	}
+}
+
+// LoadBalancer implements Interface.LoadBalancer.
+func (d *DecoratedCloud) LoadBalancer() (LoadBalancer, bool) {
+	lb, supported := d.cloud.LoadBalancer()
+	if !supported {
+		return nil, false
+	}
+	return &decoratedLoadBalancer{d: d, lb: lb}, true
+}
+
+// Instances implements Interface.Instances.
+func (d *DecoratedCloud) Instances() (Instances, bool) {
+	instances, supported := d.cloud.Instances()
+	if !supported {
+		return nil, false
+	}
+	return &decoratedInstances{d: d, instances: instances}, true
+}
+
+// Zones implements Interface.Zones.
+func (d *DecoratedCloud) Zones() (Zones, bool) {
+	zones, supported := d.cloud.Zones()
+	if !supported {
+		return nil, false
+	}
+	return &decoratedZones{d: d, zones: zones}, true
+}
+
+// Clusters implements Interface.Clusters. Cluster enumeration is rare
+// enough that it is passed through undecorated.
+func (d *DecoratedCloud) Clusters() (Clusters, bool) {
+	return d.cloud.Clusters()
+}
+
+// Routes implements Interface.Routes.
+func (d *DecoratedCloud) Routes() (Routes, bool) {
+	routes, supported := d.cloud.Routes()
+	if !supported {
+		return nil, false
+	}
+	return &decoratedRoutes{d: d, routes: routes}, true
+}
+
+// ProviderName implements Interface.ProviderName.
+func (d *DecoratedCloud) ProviderName() string {
+	return d.cloud.ProviderName()
+}
+
+// ScrubDNS implements Interface.ScrubDNS.
+func (d *DecoratedCloud) ScrubDNS(nameservers, searches []string) (nsOut, srchOut []string) {
+	return d.cloud.ScrubDNS(nameservers, searches)
+}
+
+type decoratedInstances struct {
+	d         *DecoratedCloud
+	instances Instances
+}
+
+func (i *decoratedInstances) NodeAddresses(name string) ([]api.NodeAddress, error) {
+	value, err := i.d.cachedCall("instances.NodeAddresses", name, func() (interface{}, error) {
+		return i.instances.NodeAddresses(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]api.NodeAddress), nil
+}
+
+func (i *decoratedInstances) ExternalID(name string) (string, error) {
+	value, err := i.d.cachedCall("instances.ExternalID", name, func() (interface{}, error) {
+		return i.instances.ExternalID(name)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+func (i *decoratedInstances) InstanceID(name string) (string, error) {
+	value, err := i.d.cachedCall("instances.InstanceID", name, func() (interface{}, error) {
+		return i.instances.InstanceID(name)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+func (i *decoratedInstances) InstanceType(name string) (string, error) {
+	value, err := i.d.cachedCall("instances.InstanceType", name, func() (interface{}, error) {
+		return i.instances.InstanceType(name)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+func (i *decoratedInstances) List(filter string) ([]string, error) {
+	value, err := i.d.cachedCall("instances.List", filter, func() (interface{}, error) {
+		return i.instances.List(filter)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]string), nil
+}
+
+func (i *decoratedInstances) AddSSHKeyToAllInstances(user string, keyData []byte) error {
+	_, err := i.d.call("instances.AddSSHKeyToAllInstances", func() (interface{}, error) {
+		return nil, i.instances.AddSSHKeyToAllInstances(user, keyData)
+	})
+	return err
+}
+
+func (i *decoratedInstances) CurrentNodeName(hostname string) (string, error) {
+	value, err := i.d.cachedCall("instances.CurrentNodeName", hostname, func() (interface{}, error) {
+		return i.instances.CurrentNodeName(hostname)
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+type decoratedZones struct {
+	d     *DecoratedCloud
+	zones Zones
+}
+
+func (z *decoratedZones) GetZone() (Zone, error) {
+	value, err := z.d.cachedCall("zones.GetZone", "", func() (interface{}, error) {
+		return z.zones.GetZone()
+	})
+	if err != nil {
+		return Zone{}, err
+	}
+	return value.(Zone), nil
+}
+
+type decoratedRoutes struct {
+	d      *DecoratedCloud
+	routes Routes
+}
+
+func (r *decoratedRoutes) ListRoutes(clusterName string) ([]*Route, error) {
+	value, err := r.d.cachedCall("routes.ListRoutes", clusterName, func() (interface{}, error) {
+		return r.routes.ListRoutes(clusterName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]*Route), nil
+}
+
+func (r *decoratedRoutes) CreateRoute(clusterName string, nameHint string, route *Route) error {
+	_, err := r.d.call("routes.CreateRoute", func() (interface{}, error) {
+		return nil, r.routes.CreateRoute(clusterName, nameHint, route)
+	})
+	r.d.invalidate("routes.ListRoutes")
+	return err
+}
+
+func (r *decoratedRoutes) DeleteRoute(clusterName string, route *Route) error {
+	_, err := r.d.call("routes.DeleteRoute", func() (interface{}, error) {
+		return nil, r.routes.DeleteRoute(clusterName, route)
+	})
+	r.d.invalidate("routes.ListRoutes")
+	return err
+}
+
+type decoratedLoadBalancer struct {
+	d  *DecoratedCloud
+	lb LoadBalancer
+}
+
+type loadBalancerStatus struct {
+	status *api.LoadBalancerStatus
+	exists bool
+}
+
+func (l *decoratedLoadBalancer) GetLoadBalancer(name, region string) (*api.LoadBalancerStatus, bool, error) {
+	value, err := l.d.cachedCall("loadbalancer.GetLoadBalancer", name+"/"+region, func() (interface{}, error) {
+		status, exists, err := l.lb.GetLoadBalancer(name, region)
+		return loadBalancerStatus{status: status, exists: exists}, err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	result := value.(loadBalancerStatus)
+	return result.status, result.exists, nil
+}
+
+func (l *decoratedLoadBalancer) EnsureLoadBalancer(name, region string, loadBalancerIP net.IP, ports []*api.ServicePort, hosts []string, serviceName types.NamespacedName, affinityType api.ServiceAffinity, annotations ServiceAnnotation) (*api.LoadBalancerStatus, error) {
+	value, err := l.d.call("loadbalancer.EnsureLoadBalancer", func() (interface{}, error) {
+		return l.lb.EnsureLoadBalancer(name, region, loadBalancerIP, ports, hosts, serviceName, affinityType, annotations)
+	})
+	l.d.invalidate("loadbalancer.GetLoadBalancer")
+	if err != nil {
+		return nil, err
+	}
+	return value.(*api.LoadBalancerStatus), nil
+}
+
+func (l *decoratedLoadBalancer) UpdateLoadBalancer(name, region string, hosts []string) error {
+	_, err := l.d.call("loadbalancer.UpdateLoadBalancer", func() (interface{}, error) {
+		return nil, l.lb.UpdateLoadBalancer(name, region, hosts)
+	})
+	l.d.invalidate("loadbalancer.GetLoadBalancer")
+	return err
+}
+
+func (l *decoratedLoadBalancer) EnsureLoadBalancerDeleted(name, region string) error {
+	_, err := l.d.call("loadbalancer.EnsureLoadBalancerDeleted", func() (interface{}, error) {
+		return nil, l.lb.EnsureLoadBalancerDeleted(name, region)
+	})
+	l.d.invalidate("loadbalancer.GetLoadBalancer")
+	return err
+}
+
+var _ Interface = &DecoratedCloud{}
@@ -0,0 +1,218 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api"
+)
+
+// testCloud implements Interface, recording how often each underlying
+// call actually reaches it so tests can tell cache hits from misses.
+type testCloud struct {
+	instances testInstances
+	routes    testRoutes
+}
+
+func (c *testCloud) LoadBalancer() (LoadBalancer, bool) { return nil, false }
+func (c *testCloud) Instances() (Instances, bool)       { return &c.instances, true }
+func (c *testCloud) Zones() (Zones, bool)               { return nil, false }
+func (c *testCloud) Clusters() (Clusters, bool)         { return nil, false }
+func (c *testCloud) Routes() (Routes, bool)             { return &c.routes, true }
+func (c *testCloud) ProviderName() string               { return "test" }
+func (c *testCloud) ScrubDNS(nameservers, searches []string) (nsOut, srchOut []string) {
+	return nameservers, searches
+}
+
+type testInstances struct {
+	nodeAddressesCalls int
+	nodeAddressesErr   error
+}
+
+func (i *testInstances) NodeAddresses(name string) ([]api.NodeAddress, error) {
+	i.nodeAddressesCalls++
+	if i.nodeAddressesErr != nil {
+		return nil, i.nodeAddressesErr
+	}
+	return []api.NodeAddress{{Type: api.NodeInternalIP, Address: "10.0.0.1"}}, nil
+}
+
+func (i *testInstances) ExternalID(name string) (string, error) { return name, nil }
+func (i *testInstances) InstanceID(name string) (string, error) { return "/zone/" + name, nil }
+func (i *testInstances) InstanceType(name string) (string, error) {
+	return "t.test", nil
+}
+func (i *testInstances) List(filter string) ([]string, error) { return nil, nil }
+func (i *testInstances) AddSSHKeyToAllInstances(user string, keyData []byte) error {
+	return nil
+}
+func (i *testInstances) CurrentNodeName(hostname string) (string, error) { return hostname, nil }
+
+type testRoutes struct {
+	listRoutesCalls int
+}
+
+func (r *testRoutes) ListRoutes(clusterName string) ([]*Route, error) {
+	r.listRoutesCalls++
+	return []*Route{{Name: clusterName + "-10.244.0.0/24", DestinationCIDR: "10.244.0.0/24"}}, nil
+}
+
+func (r *testRoutes) CreateRoute(clusterName string, nameHint string, route *Route) error {
+	return nil
+}
+
+func (r *testRoutes) DeleteRoute(clusterName string, route *Route) error { return nil }
+
+// countingRateLimiter implements util.RateLimiter and counts Accepts.
+type countingRateLimiter struct {
+	accepts int
+}
+
+func (l *countingRateLimiter) TryAccept() bool     { return true }
+func (l *countingRateLimiter) Accept()             { l.accepts++ }
+func (l *countingRateLimiter) Stop()               {}
+func (l *countingRateLimiter) Saturation() float64 { return 0 }
+
+func TestCachedCallExpiry(t *testing.T) {
+	cloud := &testCloud{}
+	decorated := NewDecoratedCloud(cloud, DecoratorConfig{CacheTTL: 50 * time.Millisecond})
+	instances, supported := decorated.Instances()
+	if !supported {
+		t.Fatalf("expected Instances to be supported")
+	}
+
+	for i := 0; i < 3; i++ {
+		addrs, err := instances.NodeAddresses("node-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(addrs) != 1 || addrs[0].Address != "10.0.0.1" {
+			t.Errorf("unexpected addresses: %v", addrs)
+		}
+	}
+	if cloud.instances.nodeAddressesCalls != 1 {
+		t.Errorf("expected 1 underlying call while cached, got %d", cloud.instances.nodeAddressesCalls)
+	}
+
+	// A different key must not be served from the node-1 entry.
+	if _, err := instances.NodeAddresses("node-2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cloud.instances.nodeAddressesCalls != 2 {
+		t.Errorf("expected 2 underlying calls after a different key, got %d", cloud.instances.nodeAddressesCalls)
+	}
+
+	// After the TTL the entry must be refreshed.
+	time.Sleep(100 * time.Millisecond)
+	if _, err := instances.NodeAddresses("node-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cloud.instances.nodeAddressesCalls != 3 {
+		t.Errorf("expected 3 underlying calls after expiry, got %d", cloud.instances.nodeAddressesCalls)
+	}
+
+	metrics := decorated.Metrics()["instances.NodeAddresses"]
+	if metrics.Calls != 5 || metrics.CacheHits != 2 {
+		t.Errorf("expected 5 calls with 2 cache hits, got %+v", metrics)
+	}
+}
+
+func TestCachedCallError(t *testing.T) {
+	cloud := &testCloud{}
+	cloud.instances.nodeAddressesErr = errors.New("cloud is down")
+	decorated := NewDecoratedCloud(cloud, DecoratorConfig{CacheTTL: time.Hour})
+	instances, _ := decorated.Instances()
+
+	for i := 0; i < 2; i++ {
+		if _, err := instances.NodeAddresses("node-1"); err == nil {
+			t.Fatalf("expected an error")
+		}
+	}
+	// Errors are never cached.
+	if cloud.instances.nodeAddressesCalls != 2 {
+		t.Errorf("expected 2 underlying calls, got %d", cloud.instances.nodeAddressesCalls)
+	}
+	if metrics := decorated.Metrics()["instances.NodeAddresses"]; metrics.Errors != 2 {
+		t.Errorf("expected 2 errors, got %+v", metrics)
+	}
+}
+
+func TestMutationInvalidatesCache(t *testing.T) {
+	cloud := &testCloud{}
+	decorated := NewDecoratedCloud(cloud, DecoratorConfig{CacheTTL: time.Hour})
+	routes, supported := decorated.Routes()
+	if !supported {
+		t.Fatalf("expected Routes to be supported")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := routes.ListRoutes("a"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if cloud.routes.listRoutesCalls != 1 {
+		t.Errorf("expected 1 underlying call while cached, got %d", cloud.routes.listRoutesCalls)
+	}
+
+	if err := routes.CreateRoute("a", "hint", &Route{DestinationCIDR: "10.244.1.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := routes.ListRoutes("a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cloud.routes.listRoutesCalls != 2 {
+		t.Errorf("expected CreateRoute to invalidate the ListRoutes cache, got %d underlying calls", cloud.routes.listRoutesCalls)
+	}
+
+	if err := routes.DeleteRoute("a", &Route{DestinationCIDR: "10.244.1.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := routes.ListRoutes("a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cloud.routes.listRoutesCalls != 3 {
+		t.Errorf("expected DeleteRoute to invalidate the ListRoutes cache, got %d underlying calls", cloud.routes.listRoutesCalls)
+	}
+}
+
+func TestRateLimiterApplied(t *testing.T) {
+	cloud := &testCloud{}
+	decorated := NewDecoratedCloud(cloud, DecoratorConfig{CacheTTL: time.Hour, QPS: 10, Burst: 1})
+	limiter := &countingRateLimiter{}
+	decorated.limiter = limiter
+
+	instances, _ := decorated.Instances()
+	routes, _ := decorated.Routes()
+
+	// A cache miss and a mutation each consume a token; a cache hit must
+	// not.
+	if _, err := instances.NodeAddresses("node-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := instances.NodeAddresses("node-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := routes.CreateRoute("a", "hint", &Route{DestinationCIDR: "10.244.1.0/24"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limiter.accepts != 2 {
+		t.Errorf("expected 2 rate limited calls, got %d", limiter.accepts)
+	}
+}
@@ -17,13 +17,18 @@ limitations under the License.
 package aws
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -32,6 +37,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -44,7 +50,6 @@ import (
 	"k8s.io/kubernetes/pkg/cloudprovider"
 	"k8s.io/kubernetes/pkg/credentialprovider/aws"
 	"k8s.io/kubernetes/pkg/types"
-	"k8s.io/kubernetes/pkg/util/sets"
 
 	"github.com/golang/glog"
 	"k8s.io/kubernetes/pkg/api/unversioned"
@@ -58,6 +63,49 @@ const TagNameKubernetesCluster = "KubernetesCluster"
 // The tag name we use to differentiate multiple services. Used currently for ELBs only.
 const TagNameKubernetesService = "kubernetes.io/service-name"
 
+// The tag name we use to reflect a node's schedulability on its instance, so
+// cordoned nodes are visible in the EC2 console and to tag-based tooling
+const TagNameKubernetesUnschedulable = "kubernetes.io/unschedulable"
+
+// The tag name a subnet can carry to mark it as intended for ELBs; when any
+// subnet in the VPC carries it, subnets without it are not considered
+const TagNameSubnetELB = "kubernetes.io/role/elb"
+
+// The tag name that marks an instance as the cluster's master, used to
+// answer Clusters.Master queries
+const TagNameRoleMaster = "kubernetes.io/role/master"
+
+// Values for the NodeNameStrategy cloud config option
+const (
+	NodeNameStrategyPrivateDNSName = "private-dns-name"
+	NodeNameStrategyNameTag        = "name-tag"
+)
+
+// Annotations a service can carry to configure the ELB created for it.
+const (
+	// ServiceAnnotationLoadBalancerCertificate names the IAM or ACM server
+	// certificate (by ARN) the load balancer terminates TLS with.
+	ServiceAnnotationLoadBalancerCertificate = "service.beta.kubernetes.io/aws-load-balancer-ssl-cert"
+	// ServiceAnnotationLoadBalancerSSLPorts lists which ports terminate
+	// TLS, as a comma-separated list of port numbers or names. The default
+	// of "*" terminates TLS on every port when a certificate is given.
+	ServiceAnnotationLoadBalancerSSLPorts = "service.beta.kubernetes.io/aws-load-balancer-ssl-ports"
+	// ServiceAnnotationLoadBalancerBackendProtocol is the protocol the
+	// backend pods speak. With http or https the TLS listeners are HTTPS
+	// rather than raw SSL, so the ELB can insert X-Forwarded-For headers.
+	ServiceAnnotationLoadBalancerBackendProtocol = "service.beta.kubernetes.io/aws-load-balancer-backend-protocol"
+	// ServiceAnnotationLoadBalancerCrossZone enables ("true") or disables
+	// ("false") cross-zone load balancing, overriding the cloud config
+	// default. Without it, a multi-AZ cluster with an uneven spread of
+	// nodes gets badly skewed traffic.
+	ServiceAnnotationLoadBalancerCrossZone = "service.beta.kubernetes.io/aws-load-balancer-cross-zone-load-balancing-enabled"
+	// ServiceAnnotationLoadBalancerSecurityGroup names a preexisting
+	// security group (by ID) to attach to the ELB instead of creating
+	// one, overriding the cloud config default. The group's ingress
+	// rules are still reconciled with the service's ports.
+	ServiceAnnotationLoadBalancerSecurityGroup = "service.beta.kubernetes.io/aws-load-balancer-security-group"
+)
+
 // We sometimes read to see if something exists; then try to create it if we didn't find it
 // This can fail once in a consistent system if done in parallel
 // In an eventually consistent system, it could fail unboundedly
@@ -69,6 +117,15 @@ const MaxReadThenCreateRetries = 30
 // need hardcoded defaults.
 const DefaultVolumeType = "gp2"
 
+// AWS limits for provisioned IOPS on io1 volumes. We check these before
+// calling the API so a bad request fails with a useful message instead of an
+// AWS error code.
+const (
+	minProvisionedIOPS     = 100
+	maxProvisionedIOPS     = 20000
+	maxIOPSToCapacityRatio = 30
+)
+
 // Used to call aws_credentials.Init() just once
 var once sync.Once
 
@@ -86,6 +143,8 @@ type AWSServices interface {
 type EC2 interface {
 	// Query EC2 for instances matching the filter
 	DescribeInstances(request *ec2.DescribeInstancesInput) ([]*ec2.Instance, error)
+	// Query EC2 for the status checks and scheduled events of instances
+	DescribeInstanceStatus(request *ec2.DescribeInstanceStatusInput) ([]*ec2.InstanceStatus, error)
 
 	// Attach a volume to an instance
 	AttachVolume(*ec2.AttachVolumeInput) (*ec2.VolumeAttachment, error)
@@ -97,6 +156,8 @@ type EC2 interface {
 	CreateVolume(request *ec2.CreateVolumeInput) (resp *ec2.Volume, err error)
 	// Delete an EBS volume
 	DeleteVolume(*ec2.DeleteVolumeInput) (*ec2.DeleteVolumeOutput, error)
+	// Create a snapshot of an EBS volume
+	CreateSnapshot(request *ec2.CreateSnapshotInput) (*ec2.Snapshot, error)
 
 	DescribeSecurityGroups(request *ec2.DescribeSecurityGroupsInput) ([]*ec2.SecurityGroup, error)
 
@@ -128,12 +189,20 @@ type ELB interface {
 	DetachLoadBalancerFromSubnets(*elb.DetachLoadBalancerFromSubnetsInput) (*elb.DetachLoadBalancerFromSubnetsOutput, error)
 	AttachLoadBalancerToSubnets(*elb.AttachLoadBalancerToSubnetsInput) (*elb.AttachLoadBalancerToSubnetsOutput, error)
 
+	EnableAvailabilityZonesForLoadBalancer(*elb.EnableAvailabilityZonesForLoadBalancerInput) (*elb.EnableAvailabilityZonesForLoadBalancerOutput, error)
+	DisableAvailabilityZonesForLoadBalancer(*elb.DisableAvailabilityZonesForLoadBalancerInput) (*elb.DisableAvailabilityZonesForLoadBalancerOutput, error)
+
 	CreateLoadBalancerListeners(*elb.CreateLoadBalancerListenersInput) (*elb.CreateLoadBalancerListenersOutput, error)
 	DeleteLoadBalancerListeners(*elb.DeleteLoadBalancerListenersInput) (*elb.DeleteLoadBalancerListenersOutput, error)
 
 	ApplySecurityGroupsToLoadBalancer(*elb.ApplySecurityGroupsToLoadBalancerInput) (*elb.ApplySecurityGroupsToLoadBalancerOutput, error)
 
 	ConfigureHealthCheck(*elb.ConfigureHealthCheckInput) (*elb.ConfigureHealthCheckOutput, error)
+
+	AddTags(*elb.AddTagsInput) (*elb.AddTagsOutput, error)
+
+	DescribeLoadBalancerAttributes(*elb.DescribeLoadBalancerAttributesInput) (*elb.DescribeLoadBalancerAttributesOutput, error)
+	ModifyLoadBalancerAttributes(*elb.ModifyLoadBalancerAttributesInput) (*elb.ModifyLoadBalancerAttributesOutput, error)
 }
 
 // This is a simple pass-through of the Autoscaling client interface, which allows for testing
@@ -151,6 +220,15 @@ type EC2Metadata interface {
 type VolumeOptions struct {
 	CapacityGB int
 	Tags       *map[string]string
+	// VolumeType is the EBS volume type: standard, gp2 or io1. Empty uses
+	// DefaultVolumeType.
+	VolumeType string
+	// IOPS is the number of provisioned IOPS; only valid with io1 volumes.
+	IOPS int64
+	// AvailabilityZone is the zone to create the volume in; it must belong
+	// to the provider's region. Empty spreads volumes across the zones
+	// that have cluster nodes.
+	AvailabilityZone string
 }
 
 // Volumes is an interface for managing cloud-provisioned volumes
@@ -167,6 +245,12 @@ type Volumes interface {
 
 	// Create a volume with the specified options
 	CreateDisk(volumeOptions *VolumeOptions) (volumeName string, err error)
+	// Create a snapshot of the specified volume
+	// Returns the ID of the new snapshot
+	SnapshotVolume(volumeName string, description string) (snapshotID string, err error)
+	// Create a volume restoring the specified snapshot
+	// A zero CapacityGB in the options takes the size of the snapshot
+	CreateVolumeFromSnapshot(snapshotID string, volumeOptions *VolumeOptions) (volumeName string, err error)
 	// Delete the specified volume
 	// Returns true iff the volume was deleted
 	// If the was not found, returns (false, nil)
@@ -174,6 +258,14 @@ type Volumes interface {
 
 	// Get labels to apply to volume on creation
 	GetVolumeLabels(volumeName string) (map[string]string, error)
+
+	// Check if the volume is already attached to the instance
+	// instanceName can be empty to mean "the instance on which we are running"
+	DiskIsAttached(diskName string, instanceName string) (bool, error)
+	// Check if a list of volumes are attached to the instance
+	// Returns a map from diskName to attachment state, with an entry for
+	// every disk in diskNames
+	DisksAreAttached(diskNames []string, instanceName string) (map[string]bool, error)
 }
 
 // InstanceGroups is an interface for managing cloud-managed instance groups / autoscaling instance groups
@@ -206,6 +298,10 @@ type AWSCloud struct {
 	// The AWS instance that we are running on
 	selfAWSInstance *awsInstance
 
+	// Round-robin index for spreading new volumes across zones, guarded
+	// by mutex
+	volumeZoneIndex int
+
 	mutex sync.Mutex
 }
 
@@ -219,6 +315,63 @@ type AWSCloudConfig struct {
 
 		KubernetesClusterTag string
 
+		//The IAM role (by ARN) to assume via STS for all AWS API calls,
+		//so the control plane can manage resources in a different
+		//account than the one hosting the masters. The base credentials
+		//must be allowed to assume the role.
+		RoleARN string
+		//Optional external ID to pass to STS when assuming RoleARN.
+		RoleARNExternalID string
+		//Session name to use when assuming RoleARN; visible in the
+		//other account's CloudTrail logs.
+		RoleSessionName string
+
+		//How Kubernetes node names map to EC2 instances:
+		//"private-dns-name" (the default) matches the instance's private
+		//DNS name; "name-tag" matches the instance's Name tag, for VPCs
+		//with custom DHCP option sets where hostnames do not match the
+		//EC2 private DNS name. Instance IDs are always accepted.
+		NodeNameStrategy string
+
+		//Override the endpoints the EC2, ELB and metadata clients talk
+		//to, e.g. to run the provider against a local test stack
+		//(localstack/moto) or an AWS-compatible private cloud. When
+		//unset the SDK derives the endpoints from the region.
+		Ec2Endpoint      string
+		ElbEndpoint      string
+		MetadataEndpoint string
+
+		//Talk plain HTTP to the endpoints above; local test stacks
+		//usually do not serve TLS.
+		DisableSSL bool
+
+		//Proxy URL for EC2, ELB and autoscaling API calls, for clusters
+		//that reach AWS through a corporate proxy. When unset the
+		//standard HTTP(S)_PROXY / NO_PROXY environment variables apply.
+		HttpProxy string
+
+		//The ID of the VPC the cluster runs in. When unset it is
+		//discovered from the instance's own metadata, so it normally
+		//only needs to be set when running outside the cluster.
+		VPCID string
+
+		//Log mutating AWS API calls and return synthetic success instead
+		//of executing them, so the provider's intended actions can be
+		//previewed against a production account. Read-only calls are
+		//still made.
+		DryRun bool
+
+		//Volume type for EBS volumes created without an explicit type:
+		//"standard" or "gp2". Defaults to gp2; clusters that need
+		//magnetic volumes for cost reasons can set "standard".
+		DefaultVolumeType string
+
+		//When a volume is still not detached after this many seconds,
+		//escalate to a forced detach (DetachVolume with Force=true).
+		//A forced detach can corrupt the filesystem if the volume is
+		//still mounted, so this is disabled (0) by default.
+		VolumeForceDetachSeconds int
+
 		//The aws provider creates an inbound rule per load balancer on the node security
 		//group. However, this can run into the AWS security group rule limit of 50 if
 		//many LoadBalancers are created.
@@ -227,6 +380,16 @@ type AWSCloudConfig struct {
 		//has setup a rule that allows inbound traffic on kubelet ports from the
 		//local VPC subnet (so load balancers can access it). E.g. 10.82.0.0/16 30000-32000.
 		DisableSecurityGroupIngress bool
+
+		//Enable cross-zone load balancing on created ELBs by default.
+		//Individual services can override this with the
+		//cross-zone-load-balancing-enabled service annotation.
+		ElbCrossZoneLoadBalancing bool
+
+		//Attach this preexisting security group (by ID) to created ELBs
+		//instead of creating one per load balancer. Individual services
+		//can override this with the security-group service annotation.
+		ElbSecurityGroup string
 	}
 }
 
@@ -237,24 +400,69 @@ type awsSdkEC2 struct {
 
 type awsSDKProvider struct {
 	creds *credentials.Credentials
+	cfg   *AWSCloudConfig
 
 	mutex          sync.Mutex
 	regionDelayers map[string]*CrossRequestRetryDelay
 }
 
-func newAWSSDKProvider(creds *credentials.Credentials) *awsSDKProvider {
+func newAWSSDKProvider(creds *credentials.Credentials, cfg *AWSCloudConfig) *awsSDKProvider {
 	return &awsSDKProvider{
 		creds:          creds,
+		cfg:            cfg,
 		regionDelayers: make(map[string]*CrossRequestRetryDelay),
 	}
 }
 
+// awsConfig builds the SDK configuration for a service client, applying
+// any endpoint override from the cloud config
+func (p *awsSDKProvider) awsConfig(regionName, endpointOverride string) (*aws.Config, error) {
+	config := &aws.Config{
+		Region:      &regionName,
+		Credentials: p.creds,
+		MaxRetries:  aws.Int(apiMaxRetries),
+	}
+	if endpointOverride != "" {
+		config.Endpoint = aws.String(endpointOverride)
+	}
+	if p.cfg.Global.DisableSSL {
+		config.DisableSSL = aws.Bool(true)
+	}
+
+	// Build an explicit HTTP client so proxy configuration is honored:
+	// the http-proxy config option wins, otherwise the standard
+	// HTTP(S)_PROXY / NO_PROXY environment variables apply.
+	proxy := http.ProxyFromEnvironment
+	if p.cfg.Global.HttpProxy != "" {
+		proxyURL, err := url.Parse(p.cfg.Global.HttpProxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid http-proxy URL (%s): %v", p.cfg.Global.HttpProxy, err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	config.HTTPClient = &http.Client{
+		Transport: &http.Transport{Proxy: proxy},
+	}
+
+	return config, nil
+}
+
 func (p *awsSDKProvider) addHandlers(regionName string, h *request.Handlers) {
 	h.Sign.PushFrontNamed(request.NamedHandler{
 		Name: "k8s/logger",
 		Fn:   awsHandlerLogger,
 	})
 
+	h.Sign.PushFrontNamed(request.NamedHandler{
+		Name: "k8s/api-request-metrics",
+		Fn:   awsMetricsRequestHandler,
+	})
+
+	h.AfterRetry.PushBackNamed(request.NamedHandler{
+		Name: "k8s/api-result-metrics",
+		Fn:   awsMetricsAfterRetryHandler,
+	})
+
 	delayer := p.getCrossRequestRetryDelay(regionName)
 	if delayer != nil {
 		h.Sign.PushFrontNamed(request.NamedHandler{
@@ -290,11 +498,18 @@ func (p *awsSDKProvider) getCrossRequestRetryDelay(regionName string) *CrossRequ
 	return delayer
 }
 
+// apiMaxRetries is the per-operation retry budget we give aws-sdk-go; the
+// SDK retries throttling and 5xx errors with exponential backoff and jitter,
+// so a larger budget lets big clusters ride out RequestLimitExceeded bursts
+// instead of failing the operation.
+const apiMaxRetries = 8
+
 func (p *awsSDKProvider) Compute(regionName string) (EC2, error) {
-	service := ec2.New(session.New(&aws.Config{
-		Region:      &regionName,
-		Credentials: p.creds,
-	}))
+	awsConfig, err := p.awsConfig(regionName, p.cfg.Global.Ec2Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	service := ec2.New(session.New(awsConfig))
 
 	p.addHandlers(regionName, &service.Handlers)
 
@@ -305,10 +520,11 @@ func (p *awsSDKProvider) Compute(regionName string) (EC2, error) {
 }
 
 func (p *awsSDKProvider) LoadBalancing(regionName string) (ELB, error) {
-	elbClient := elb.New(session.New(&aws.Config{
-		Region:      &regionName,
-		Credentials: p.creds,
-	}))
+	awsConfig, err := p.awsConfig(regionName, p.cfg.Global.ElbEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	elbClient := elb.New(session.New(awsConfig))
 
 	p.addHandlers(regionName, &elbClient.Handlers)
 
@@ -316,10 +532,11 @@ func (p *awsSDKProvider) LoadBalancing(regionName string) (ELB, error) {
 }
 
 func (p *awsSDKProvider) Autoscaling(regionName string) (ASG, error) {
-	client := autoscaling.New(session.New(&aws.Config{
-		Region:      &regionName,
-		Credentials: p.creds,
-	}))
+	awsConfig, err := p.awsConfig(regionName, "")
+	if err != nil {
+		return nil, err
+	}
+	client := autoscaling.New(session.New(awsConfig))
 
 	p.addHandlers(regionName, &client.Handlers)
 
@@ -327,7 +544,27 @@ func (p *awsSDKProvider) Autoscaling(regionName string) (ASG, error) {
 }
 
 func (p *awsSDKProvider) Metadata() (EC2Metadata, error) {
-	client := ec2metadata.New(session.New(&aws.Config{}))
+	// Transient metadata service failures abort provider initialization,
+	// so give the client the same retry budget as the API clients; the
+	// SDK retries with exponential backoff.
+	awsConfig := &aws.Config{
+		MaxRetries: aws.Int(apiMaxRetries),
+	}
+	if p.cfg.Global.MetadataEndpoint != "" {
+		awsConfig.Endpoint = aws.String(p.cfg.Global.MetadataEndpoint)
+	}
+	client := ec2metadata.New(session.New(awsConfig))
+
+	client.Handlers.Sign.PushFrontNamed(request.NamedHandler{
+		Name: "k8s/logger",
+		Fn:   awsHandlerLogger,
+	})
+
+	client.Handlers.AfterRetry.PushBackNamed(request.NamedHandler{
+		Name: "k8s/api-result-metrics",
+		Fn:   awsMetricsAfterRetryHandler,
+	})
+
 	return client, nil
 }
 
@@ -367,6 +604,8 @@ func (self *AWSCloud) AddSSHKeyToAllInstances(user string, keyData []byte) error
 	return errors.New("unimplemented")
 }
 
+// Implementation of Instances.CurrentNodeName
+// On AWS the node name is the instance's private DNS name, not the hostname
 func (a *AWSCloud) CurrentNodeName(hostname string) (string, error) {
 	selfInstance, err := a.getSelfAWSInstance()
 	if err != nil {
@@ -401,6 +640,30 @@ func (self *awsSdkEC2) DescribeInstances(request *ec2.DescribeInstancesInput) ([
 	return results, nil
 }
 
+// Implements EC2.DescribeInstanceStatus
+func (self *awsSdkEC2) DescribeInstanceStatus(request *ec2.DescribeInstanceStatusInput) ([]*ec2.InstanceStatus, error) {
+	// Statuses are paged
+	results := []*ec2.InstanceStatus{}
+	var nextToken *string
+
+	for {
+		response, err := self.ec2.DescribeInstanceStatus(request)
+		if err != nil {
+			return nil, fmt.Errorf("error listing AWS instance statuses: %v", err)
+		}
+
+		results = append(results, response.InstanceStatuses...)
+
+		nextToken = response.NextToken
+		if isNilOrEmpty(nextToken) {
+			break
+		}
+		request.NextToken = nextToken
+	}
+
+	return results, nil
+}
+
 // Implements EC2.DescribeSecurityGroups
 func (s *awsSdkEC2) DescribeSecurityGroups(request *ec2.DescribeSecurityGroupsInput) ([]*ec2.SecurityGroup, error) {
 	// Security groups are not paged
@@ -451,6 +714,10 @@ func (s *awsSdkEC2) DeleteVolume(request *ec2.DeleteVolumeInput) (*ec2.DeleteVol
 	return s.ec2.DeleteVolume(request)
 }
 
+func (s *awsSdkEC2) CreateSnapshot(request *ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+	return s.ec2.CreateSnapshot(request)
+}
+
 func (s *awsSdkEC2) DescribeSubnets(request *ec2.DescribeSubnetsInput) ([]*ec2.Subnet, error) {
 	// Subnets are not paged
 	response, err := s.ec2.DescribeSubnets(request)
@@ -503,6 +770,18 @@ func (s *awsSdkEC2) ModifyInstanceAttribute(request *ec2.ModifyInstanceAttribute
 
 func init() {
 	cloudprovider.RegisterCloudProvider(ProviderName, func(config io.Reader) (cloudprovider.Interface, error) {
+		// Buffer the config; we pre-parse it here for the assume-role
+		// options and newAWSCloud reads it again in full.
+		var body []byte
+		if config != nil {
+			var err error
+			body, err = ioutil.ReadAll(config)
+			if err != nil {
+				return nil, err
+			}
+			config = bytes.NewReader(body)
+		}
+
 		creds := credentials.NewChainCredentials(
 			[]credentials.Provider{
 				&credentials.EnvProvider{},
@@ -511,7 +790,29 @@ func init() {
 				},
 				&credentials.SharedCredentialsProvider{},
 			})
-		aws := newAWSSDKProvider(creds)
+
+		var cfg AWSCloudConfig
+		if body != nil {
+			if err := gcfg.ReadInto(&cfg, bytes.NewReader(body)); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.Global.RoleARN != "" {
+			glog.Infof("AWS cloud provider will assume role %s", cfg.Global.RoleARN)
+			creds = stscreds.NewCredentials(
+				session.New(&aws.Config{Credentials: creds}),
+				cfg.Global.RoleARN,
+				func(p *stscreds.AssumeRoleProvider) {
+					if cfg.Global.RoleARNExternalID != "" {
+						p.ExternalID = aws.String(cfg.Global.RoleARNExternalID)
+					}
+					if cfg.Global.RoleSessionName != "" {
+						p.RoleSessionName = cfg.Global.RoleSessionName
+					}
+				})
+		}
+
+		aws := newAWSSDKProvider(creds, &cfg)
 		return newAWSCloud(config, aws)
 	})
 }
@@ -597,6 +898,18 @@ func newAWSCloud(config io.Reader, awsServices AWSServices) (*AWSCloud, error) {
 		return nil, fmt.Errorf("unable to read AWS cloud provider config file: %v", err)
 	}
 
+	switch cfg.Global.NodeNameStrategy {
+	case "", NodeNameStrategyPrivateDNSName, NodeNameStrategyNameTag:
+	default:
+		return nil, fmt.Errorf("invalid node name strategy in config file: %s", cfg.Global.NodeNameStrategy)
+	}
+
+	switch cfg.Global.DefaultVolumeType {
+	case "", ec2.VolumeTypeStandard, ec2.VolumeTypeGp2:
+	default:
+		return nil, fmt.Errorf("invalid default volume type in config file: %s", cfg.Global.DefaultVolumeType)
+	}
+
 	zone := cfg.Global.Zone
 	if len(zone) <= 1 {
 		return nil, fmt.Errorf("invalid AWS zone in config file: %s", zone)
@@ -626,6 +939,12 @@ func newAWSCloud(config io.Reader, awsServices AWSServices) (*AWSCloud, error) {
 		return nil, fmt.Errorf("error creating AWS autoscaling client: %v", err)
 	}
 
+	if cfg.Global.DryRun {
+		glog.Info("AWS cloud provider running in dry-run mode; mutating API calls will be logged but not executed")
+		ec2 = &dryRunEC2{ec2}
+		elb = &dryRunELB{elb}
+	}
+
 	awsCloud := &AWSCloud{
 		ec2:              ec2,
 		elb:              elb,
@@ -636,14 +955,17 @@ func newAWSCloud(config io.Reader, awsServices AWSServices) (*AWSCloud, error) {
 		availabilityZone: zone,
 	}
 
+	// Fetch our own instance's identity once, up front; later callers hit
+	// the cache instead of the (rate-limited) metadata service
+	selfInstance, err := awsCloud.getSelfAWSInstance()
+	if err != nil {
+		return nil, err
+	}
+
 	filterTags := map[string]string{}
 	if cfg.Global.KubernetesClusterTag != "" {
 		filterTags[TagNameKubernetesCluster] = cfg.Global.KubernetesClusterTag
 	} else {
-		selfInstance, err := awsCloud.getSelfAWSInstance()
-		if err != nil {
-			return nil, err
-		}
 		selfInstanceInfo, err := selfInstance.getInfo()
 		if err != nil {
 			return nil, err
@@ -671,7 +993,77 @@ func newAWSCloud(config io.Reader, awsServices AWSServices) (*AWSCloud, error) {
 }
 
 func (aws *AWSCloud) Clusters() (cloudprovider.Clusters, bool) {
-	return nil, false
+	return aws, true
+}
+
+// ListClusters lists the cluster tags found on running instances in the
+// region. Note that this deliberately looks beyond our own cluster tag, so
+// other clusters sharing the region are visible.
+func (s *AWSCloud) ListClusters() ([]string, error) {
+	filters := []*ec2.Filter{
+		newEc2Filter("instance-state-name", "running"),
+		newEc2Filter("tag-key", TagNameKubernetesCluster),
+	}
+	request := &ec2.DescribeInstancesInput{Filters: filters}
+
+	instances, err := s.ec2.DescribeInstances(request)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := map[string]bool{}
+	for _, instance := range instances {
+		if clusterName, found := findTag(instance.Tags, TagNameKubernetesCluster); found && clusterName != "" {
+			clusters[clusterName] = true
+		}
+	}
+
+	clusterNames := []string{}
+	for clusterName := range clusters {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	sort.Strings(clusterNames)
+	return clusterNames, nil
+}
+
+// Master returns the address of the cluster's master: the instance carrying
+// the master role tag, or failing that the DNS name of a load balancer in
+// front of the master.
+func (s *AWSCloud) Master(clusterName string) (string, error) {
+	filters := []*ec2.Filter{
+		newEc2Filter("instance-state-name", "running"),
+		newEc2Filter("tag:"+TagNameKubernetesCluster, clusterName),
+		newEc2Filter("tag-key", TagNameRoleMaster),
+	}
+	request := &ec2.DescribeInstancesInput{Filters: filters}
+
+	instances, err := s.ec2.DescribeInstances(request)
+	if err != nil {
+		return "", err
+	}
+	if len(instances) > 1 {
+		return "", fmt.Errorf("multiple master instances found for cluster: %s", clusterName)
+	}
+	if len(instances) == 1 {
+		instance := instances[0]
+		if !isNilOrEmpty(instance.PublicDnsName) {
+			return orEmpty(instance.PublicDnsName), nil
+		}
+		if !isNilOrEmpty(instance.PrivateIpAddress) {
+			return orEmpty(instance.PrivateIpAddress), nil
+		}
+		return "", fmt.Errorf("master instance for cluster %s has no address", clusterName)
+	}
+
+	lb, err := s.describeLoadBalancer(clusterName + "-master")
+	if err != nil {
+		return "", err
+	}
+	if lb != nil && !isNilOrEmpty(lb.DNSName) {
+		return orEmpty(lb.DNSName), nil
+	}
+
+	return "", fmt.Errorf("could not find master for cluster: %s", clusterName)
 }
 
 // ProviderName returns the cloud provider ID.
@@ -826,9 +1218,36 @@ func (aws *AWSCloud) InstanceType(name string) (string, error) {
 	}
 }
 
+// ec2TagFilterPattern converts a List pattern into an EC2 tag filter value,
+// reporting whether the conversion is possible. The EC2 API only supports
+// '*' and '?' wildcards, so any pattern containing regex metacharacters
+// has to be matched client-side instead.
+func ec2TagFilterPattern(regex string) (string, bool) {
+	if strings.ContainsAny(regex, `\^$.|?*+()[]{}`) {
+		return "", false
+	}
+	// List matches anywhere in the Name tag, not anchored
+	return "*" + regex + "*", true
+}
+
 // Return a list of instances matching regex string.
 func (s *AWSCloud) getInstancesByRegex(regex string) ([]string, error) {
+	if strings.HasPrefix(regex, "'") && strings.HasSuffix(regex, "'") {
+		glog.Infof("Stripping quotes around regex (%s)", regex)
+		regex = regex[1 : len(regex)-1]
+	}
+
 	filters := []*ec2.Filter{newEc2Filter("instance-state-name", "running")}
+
+	// Push the name match down to EC2 where the pattern allows it, so we
+	// don't have to page through every instance on big accounts; the
+	// client-side regex below then only sees the matching instances.
+	filteredServerSide := false
+	if pattern, ok := ec2TagFilterPattern(regex); ok {
+		filters = append(filters, newEc2Filter("tag:Name", pattern))
+		filteredServerSide = true
+	}
+
 	filters = s.addFilters(filters)
 	request := &ec2.DescribeInstancesInput{
 		Filters: filters,
@@ -838,15 +1257,10 @@ func (s *AWSCloud) getInstancesByRegex(regex string) ([]string, error) {
 	if err != nil {
 		return []string{}, err
 	}
-	if len(instances) == 0 {
+	if len(instances) == 0 && !filteredServerSide {
 		return []string{}, fmt.Errorf("no instances returned")
 	}
 
-	if strings.HasPrefix(regex, "'") && strings.HasSuffix(regex, "'") {
-		glog.Infof("Stripping quotes around regex (%s)", regex)
-		regex = regex[1 : len(regex)-1]
-	}
-
 	re, err := regexp.Compile(regex)
 	if err != nil {
 		return []string{}, err
@@ -881,7 +1295,6 @@ func (s *AWSCloud) getInstancesByRegex(regex string) ([]string, error) {
 
 // List is an implementation of Instances.List.
 func (aws *AWSCloud) List(filter string) ([]string, error) {
-	// TODO: Should really use tag query. No need to go regexp.
 	return aws.getInstancesByRegex(filter)
 }
 
@@ -896,19 +1309,46 @@ func (self *AWSCloud) GetZone() (cloudprovider.Zone, error) {
 // Abstraction around AWS Instance Types
 // There isn't an API to get information for a particular instance type (that I know of)
 type awsInstanceType struct {
+	// The API name of the instance type, e.g. m3.medium
+	name string
 }
 
 // Used to represent a mount device for attaching an EBS volume
-// This should be stored as a single letter (i.e. c, not sdc or /dev/sdc)
+// This should be stored as the suffix only (i.e. c or ba, not sdc or /dev/sdc)
 type mountDevice string
 
-// TODO: Also return number of mounts allowed?
+// Returns the number of EBS volumes that can be attached to an instance of
+// this type, beyond the root device.
+func (self *awsInstanceType) getMaxEBSAttachments() int {
+	// The AWS limit is 40 attachments per Linux instance
+	// (https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/volume_limits.html).
+	// We are conservative on the small previous-generation and burstable
+	// families, which become I/O bound long before that.
+	family := strings.SplitN(self.name, ".", 2)[0]
+	switch family {
+	case "t1", "t2", "m1", "c1":
+		return 16
+	default:
+		return 40
+	}
+}
+
 func (self *awsInstanceType) getEBSMountDevices() []mountDevice {
 	// See: https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/block-device-mapping-concepts.html
 	devices := []mountDevice{}
 	for c := 'f'; c <= 'p'; c++ {
 		devices = append(devices, mountDevice(fmt.Sprintf("%c", c)))
 	}
+	// Two-letter devices (/dev/xvdba etc.) are used once the recommended
+	// single letters are exhausted
+	for first := 'b'; first <= 'c'; first++ {
+		for second := 'a'; second <= 'z'; second++ {
+			devices = append(devices, mountDevice(fmt.Sprintf("%c%c", first, second)))
+		}
+	}
+	if max := self.getMaxEBSAttachments(); len(devices) > max {
+		devices = devices[:max]
+	}
 	return devices
 }
 
@@ -945,8 +1385,7 @@ func newAWSInstance(ec2 EC2, awsID, nodeName, availabilityZone, instanceType str
 
 // Gets the awsInstanceType that models the instance type of this instance
 func (self *awsInstance) getInstanceType() *awsInstanceType {
-	// TODO: Make this real
-	awsInstanceType := &awsInstanceType{}
+	awsInstanceType := &awsInstanceType{name: self.instanceType}
 	return awsInstanceType
 }
 
@@ -1000,7 +1439,7 @@ func (self *awsInstance) getMountDevice(volumeID string, assign bool) (assigned
 			if strings.HasPrefix(name, "/dev/xvd") {
 				name = name[8:]
 			}
-			if len(name) != 1 {
+			if len(name) < 1 || len(name) > 2 {
 				glog.Warningf("Unexpected EBS DeviceName: %q", aws.StringValue(blockDevice.DeviceName))
 			}
 			deviceMappings[mountDevice(name)] = aws.StringValue(blockDevice.Ebs.VolumeId)
@@ -1127,10 +1566,25 @@ func (self *awsDisk) getInfo() (*ec2.Volume, error) {
 	return volumes[0], nil
 }
 
+// volumeStateError is returned when a volume did not reach the desired
+// attachment state in time; LastState lets callers distinguish a volume
+// that was still making progress (e.g. "detaching") from one that is stuck.
+type volumeStateError struct {
+	DesiredState string
+	LastState    string
+}
+
+func (e *volumeStateError) Error() string {
+	return fmt.Sprintf("timeout waiting for volume state %q; last state was %q", e.DesiredState, e.LastState)
+}
+
 func (self *awsDisk) waitForAttachmentStatus(status string) error {
+	return self.waitForAttachmentState(status, 60)
+}
+
+func (self *awsDisk) waitForAttachmentState(status string, maxAttempts int) error {
 	// TODO: There may be a faster way to get this when we're attaching locally
 	attempt := 0
-	maxAttempts := 60
 
 	for {
 		info, err := self.getInfo()
@@ -1164,7 +1618,7 @@ func (self *awsDisk) waitForAttachmentStatus(status string) error {
 		attempt++
 		if attempt > maxAttempts {
 			glog.Warningf("Timeout waiting for volume state: actual=%s, desired=%s", attachmentStatus, status)
-			return errors.New("Timeout waiting for volume state")
+			return &volumeStateError{DesiredState: status, LastState: attachmentStatus}
 		}
 
 		time.Sleep(1 * time.Second)
@@ -1196,33 +1650,60 @@ func (s *AWSCloud) getSelfAWSInstance() (*awsInstance, error) {
 
 	i := s.selfAWSInstance
 	if i == nil {
-		instanceId, err := s.metadata.GetMetadata("instance-id")
-		if err != nil {
-			return nil, fmt.Errorf("error fetching instance-id from ec2 metadata service: %v", err)
-		}
-		// privateDnsName, err := s.metadata.GetMetadata("local-hostname")
-		// See #11543 - need to use ec2 API to get the privateDnsName in case of private dns zone e.g. mydomain.io
-		instance, err := s.getInstanceByID(instanceId)
-		if err != nil {
-			return nil, fmt.Errorf("error finding instance %s: %v", instanceId, err)
-		}
-		privateDnsName := aws.StringValue(instance.PrivateDnsName)
-		availabilityZone, err := getAvailabilityZone(s.metadata)
-		if err != nil {
-			return nil, fmt.Errorf("error fetching availability zone from ec2 metadata service: %v", err)
-		}
-		instanceType, err := getInstanceType(s.metadata)
+		var err error
+		i, err = s.fetchSelfAWSInstance()
 		if err != nil {
-			return nil, fmt.Errorf("error fetching instance type from ec2 metadata service: %v", err)
+			return nil, err
 		}
-
-		i = newAWSInstance(s.ec2, instanceId, privateDnsName, availabilityZone, instanceType)
 		s.selfAWSInstance = i
 	}
 
 	return i, nil
 }
 
+// Reads the identity of the EC2 instance on which we are running from the
+// metadata service. Normally called once, at newAWSCloud time; the result
+// is cached on AWSCloud. Callers must hold the mutex.
+func (s *AWSCloud) fetchSelfAWSInstance() (*awsInstance, error) {
+	instanceId, err := s.metadata.GetMetadata("instance-id")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching instance-id from ec2 metadata service: %v", err)
+	}
+	// privateDnsName, err := s.metadata.GetMetadata("local-hostname")
+	// See #11543 - need to use ec2 API to get the privateDnsName in case of private dns zone e.g. mydomain.io
+	instance, err := s.getInstanceByID(instanceId)
+	if err != nil {
+		return nil, fmt.Errorf("error finding instance %s: %v", instanceId, err)
+	}
+	privateDnsName := aws.StringValue(instance.PrivateDnsName)
+	instanceType, err := getInstanceType(s.metadata)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching instance type from ec2 metadata service: %v", err)
+	}
+
+	// The availability zone was already read (or configured) at
+	// construction; don't fetch it from the metadata service again
+	return newAWSInstance(s.ec2, instanceId, privateDnsName, s.availabilityZone, instanceType), nil
+}
+
+// RefreshSelfInstance re-reads our instance's metadata, replacing the copy
+// cached at construction. Mount device assignments made against the old
+// copy are carried over, since in-flight attaches depend on them.
+func (s *AWSCloud) RefreshSelfInstance() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	i, err := s.fetchSelfAWSInstance()
+	if err != nil {
+		return err
+	}
+	if s.selfAWSInstance != nil {
+		i.deviceMappings = s.selfAWSInstance.deviceMappings
+	}
+	s.selfAWSInstance = i
+	return nil
+}
+
 // Gets the awsInstance with node-name nodeName, or the 'self' instance if nodeName == ""
 func (aws *AWSCloud) getAwsInstance(nodeName string) (*awsInstance, error) {
 	var awsInstance *awsInstance
@@ -1299,9 +1780,13 @@ func (c *AWSCloud) AttachDisk(diskName string, instanceName string, readOnly boo
 		glog.V(2).Infof("AttachVolume request returned %v", attachResponse)
 	}
 
-	err = disk.waitForAttachmentStatus("attached")
-	if err != nil {
-		return "", err
+	// In dry-run mode the volume is never really attached, so there is
+	// nothing to wait for
+	if !c.cfg.Global.DryRun {
+		err = disk.waitForAttachmentStatus("attached")
+		if err != nil {
+			return "", err
+		}
 	}
 
 	attached = true
@@ -1366,7 +1851,30 @@ func (aws *AWSCloud) DetachDisk(diskName string, instanceName string) (string, e
 		}
 	}()
 
-	err = disk.waitForAttachmentStatus("detached")
+	if aws.cfg.Global.DryRun {
+		// The detach was not really issued; there is nothing to wait for
+	} else if grace := aws.cfg.Global.VolumeForceDetachSeconds; grace > 0 {
+		err = disk.waitForAttachmentState("detached", grace)
+		if err != nil {
+			stateErr, ok := err.(*volumeStateError)
+			if !ok {
+				return "", err
+			}
+			// The volume has been detaching past the grace period; escalate
+			// to a forced detach. A forced detach can corrupt the filesystem
+			// if the volume is still mounted, which is why this is opt-in.
+			glog.Warningf("Volume %s stuck in state %q on instance %s; forcing detach", disk.awsID, stateErr.LastState, awsInstance.awsID)
+			force := true
+			request.Force = &force
+			_, err = aws.ec2.DetachVolume(&request)
+			if err != nil {
+				return "", fmt.Errorf("error force-detaching EBS volume: %v", err)
+			}
+			err = disk.waitForAttachmentStatus("detached")
+		}
+	} else {
+		err = disk.waitForAttachmentStatus("detached")
+	}
 	if err != nil {
 		return "", err
 	}
@@ -1377,56 +1885,208 @@ func (aws *AWSCloud) DetachDisk(diskName string, instanceName string) (string, e
 
 // Implements Volumes.CreateVolume
 func (s *AWSCloud) CreateDisk(volumeOptions *VolumeOptions) (string, error) {
-	// TODO: Should we tag this with the cluster id (so it gets deleted when the cluster does?)
+	return s.createDisk(volumeOptions, "")
+}
 
-	request := &ec2.CreateVolumeInput{}
-	request.AvailabilityZone = &s.availabilityZone
-	volSize := int64(volumeOptions.CapacityGB)
-	request.Size = &volSize
-	request.VolumeType = aws.String(DefaultVolumeType)
-	response, err := s.ec2.CreateVolume(request)
-	if err != nil {
-		return "", err
+// Implements Volumes.CreateVolumeFromSnapshot
+func (s *AWSCloud) CreateVolumeFromSnapshot(snapshotID string, volumeOptions *VolumeOptions) (string, error) {
+	if !strings.HasPrefix(snapshotID, "snap-") {
+		return "", fmt.Errorf("Invalid format for AWS snapshot ID (%s)", snapshotID)
 	}
+	return s.createDisk(volumeOptions, snapshotID)
+}
 
-	az := orEmpty(response.AvailabilityZone)
-	awsID := orEmpty(response.VolumeId)
+// chooseVolumeZone picks an availability zone for a new volume, spreading
+// volumes round-robin across the zones that currently have cluster nodes.
+// Falls back to our own zone when no node zones are found.
+func (s *AWSCloud) chooseVolumeZone() (string, error) {
+	filters := []*ec2.Filter{newEc2Filter("instance-state-name", "running")}
+	filters = s.addFilters(filters)
+	request := &ec2.DescribeInstancesInput{
+		Filters: filters,
+	}
 
-	volumeName := "aws://" + az + "/" + awsID
+	instances, err := s.ec2.DescribeInstances(request)
+	if err != nil {
+		return "", fmt.Errorf("error listing instances for volume zone selection: %v", err)
+	}
 
-	// apply tags
-	if volumeOptions.Tags != nil {
-		if err := s.createTags(awsID, *volumeOptions.Tags); err != nil {
-			// delete the volume and hope it succeeds
-			_, delerr := s.DeleteDisk(volumeName)
-			if delerr != nil {
-				// delete did not succeed, we have a stray volume!
-				return "", fmt.Errorf("error tagging volume %s, could not delete the volume: %v", volumeName, delerr)
-			}
-			return "", fmt.Errorf("error tagging volume %s: %v", volumeName, err)
+	seen := map[string]bool{}
+	zones := []string{}
+	for _, instance := range instances {
+		if instance.Placement == nil {
+			continue
+		}
+		az := orEmpty(instance.Placement.AvailabilityZone)
+		if az != "" && !seen[az] {
+			seen[az] = true
+			zones = append(zones, az)
 		}
 	}
-	return volumeName, nil
-}
-
-// Implements Volumes.DeleteDisk
-func (aws *AWSCloud) DeleteDisk(volumeName string) (bool, error) {
-	awsDisk, err := newAWSDisk(aws, volumeName)
-	if err != nil {
-		return false, err
+	if len(zones) == 0 {
+		return s.availabilityZone, nil
 	}
-	return awsDisk.deleteVolume()
+	sort.Strings(zones)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	zone := zones[s.volumeZoneIndex%len(zones)]
+	s.volumeZoneIndex++
+	return zone, nil
 }
 
-// Implements Volumes.GetVolumeLabels
-func (c *AWSCloud) GetVolumeLabels(volumeName string) (map[string]string, error) {
-	awsDisk, err := newAWSDisk(c, volumeName)
-	if err != nil {
-		return nil, err
+// createDisk creates an EBS volume, optionally restoring a snapshot
+// into it. When restoring, a CapacityGB of zero takes the size of the
+// snapshot.
+func (s *AWSCloud) createDisk(volumeOptions *VolumeOptions, snapshotID string) (string, error) {
+	volumeType := volumeOptions.VolumeType
+	if volumeType == "" {
+		volumeType = s.cfg.Global.DefaultVolumeType
 	}
-	info, err := awsDisk.getInfo()
-	if err != nil {
-		return nil, err
+	if volumeType == "" {
+		volumeType = DefaultVolumeType
+	}
+
+	az := volumeOptions.AvailabilityZone
+	if az == "" {
+		var err error
+		az, err = s.chooseVolumeZone()
+		if err != nil {
+			return "", err
+		}
+	} else {
+		// A volume is only attachable from its own zone, so a zone in
+		// another region can never be right
+		region, err := azToRegion(az)
+		if err != nil {
+			return "", err
+		}
+		if region != s.region {
+			return "", fmt.Errorf("availability zone %s is not in region %s", az, s.region)
+		}
+	}
+
+	request := &ec2.CreateVolumeInput{}
+	request.AvailabilityZone = aws.String(az)
+	if volumeOptions.CapacityGB != 0 || snapshotID == "" {
+		volSize := int64(volumeOptions.CapacityGB)
+		request.Size = &volSize
+	}
+	if snapshotID != "" {
+		request.SnapshotId = aws.String(snapshotID)
+	}
+	request.VolumeType = aws.String(volumeType)
+
+	switch volumeType {
+	case ec2.VolumeTypeStandard, ec2.VolumeTypeGp2:
+		if volumeOptions.IOPS != 0 {
+			return "", fmt.Errorf("provisioned IOPS are only supported with the io1 volume type")
+		}
+	case ec2.VolumeTypeIo1:
+		// Reject requests AWS would reject anyway, with a message that
+		// explains the constraint rather than an API error code.
+		if volumeOptions.IOPS < minProvisionedIOPS || volumeOptions.IOPS > maxProvisionedIOPS {
+			return "", fmt.Errorf("provisioned IOPS must be between %d and %d, got %d",
+				minProvisionedIOPS, maxProvisionedIOPS, volumeOptions.IOPS)
+		}
+		if volumeOptions.IOPS > int64(volumeOptions.CapacityGB)*maxIOPSToCapacityRatio {
+			return "", fmt.Errorf("provisioned IOPS may be at most %d times the volume size in GiB; %d IOPS needs a volume of at least %d GiB",
+				maxIOPSToCapacityRatio, volumeOptions.IOPS, (volumeOptions.IOPS+maxIOPSToCapacityRatio-1)/maxIOPSToCapacityRatio)
+		}
+		request.Iops = aws.Int64(volumeOptions.IOPS)
+	default:
+		return "", fmt.Errorf("invalid AWS volume type %q", volumeType)
+	}
+
+	response, err := s.ec2.CreateVolume(request)
+	if err != nil {
+		return "", err
+	}
+
+	az = orEmpty(response.AvailabilityZone)
+	awsID := orEmpty(response.VolumeId)
+
+	volumeName := "aws://" + az + "/" + awsID
+
+	// Tag the volume with the cluster it belongs to and a Name, so
+	// volumes are attributable in the EC2 console and garbage collection
+	// can find the volumes of a deleted cluster. User-supplied tags win
+	// on conflict. createTags retries internally, since a freshly created
+	// volume is often not yet visible to CreateTags.
+	tags := map[string]string{}
+	if clusterName := s.getClusterName(); clusterName != "" {
+		tags[TagNameKubernetesCluster] = clusterName
+		tags["Name"] = clusterName + "-" + awsID
+	} else {
+		tags["Name"] = awsID
+	}
+	if volumeOptions.Tags != nil {
+		for k, v := range *volumeOptions.Tags {
+			tags[k] = v
+		}
+	}
+	if err := s.createTags(awsID, tags); err != nil {
+		// delete the volume and hope it succeeds
+		_, delerr := s.DeleteDisk(volumeName)
+		if delerr != nil {
+			// delete did not succeed, we have a stray volume!
+			return "", fmt.Errorf("error tagging volume %s, could not delete the volume: %v", volumeName, delerr)
+		}
+		return "", fmt.Errorf("error tagging volume %s: %v", volumeName, err)
+	}
+	return volumeName, nil
+}
+
+// Implements Volumes.SnapshotVolume
+func (s *AWSCloud) SnapshotVolume(volumeName string, description string) (string, error) {
+	awsDisk, err := newAWSDisk(s, volumeName)
+	if err != nil {
+		return "", err
+	}
+
+	request := &ec2.CreateSnapshotInput{}
+	request.VolumeId = aws.String(awsDisk.awsID)
+	if description != "" {
+		request.Description = aws.String(description)
+	}
+
+	response, err := s.ec2.CreateSnapshot(request)
+	if err != nil {
+		return "", fmt.Errorf("error creating snapshot of volume %s: %v", volumeName, err)
+	}
+	snapshotID := orEmpty(response.SnapshotId)
+
+	// Tag the snapshot like the volume, so snapshots are attributable to
+	// the cluster that took them.
+	tags := map[string]string{"Name": snapshotID}
+	if clusterName := s.getClusterName(); clusterName != "" {
+		tags[TagNameKubernetesCluster] = clusterName
+		tags["Name"] = clusterName + "-" + snapshotID
+	}
+	if err := s.createTags(snapshotID, tags); err != nil {
+		glog.Warningf("error tagging snapshot %s: %v", snapshotID, err)
+	}
+	return snapshotID, nil
+}
+
+// Implements Volumes.DeleteDisk
+func (aws *AWSCloud) DeleteDisk(volumeName string) (bool, error) {
+	awsDisk, err := newAWSDisk(aws, volumeName)
+	if err != nil {
+		return false, err
+	}
+	return awsDisk.deleteVolume()
+}
+
+// Implements Volumes.GetVolumeLabels
+func (c *AWSCloud) GetVolumeLabels(volumeName string) (map[string]string, error) {
+	awsDisk, err := newAWSDisk(c, volumeName)
+	if err != nil {
+		return nil, err
+	}
+	info, err := awsDisk.getInfo()
+	if err != nil {
+		return nil, err
 	}
 	labels := make(map[string]string)
 	az := aws.StringValue(info.AvailabilityZone)
@@ -1444,6 +2104,380 @@ func (c *AWSCloud) GetVolumeLabels(volumeName string) (map[string]string, error)
 	return labels, nil
 }
 
+// GetInstanceLabels gets the failure-domain labels (zone and region) to
+// apply to the node backed by the named instance. They mirror the labels
+// GetVolumeLabels puts on PersistentVolumes, so pods using a zonal EBS
+// volume can be scheduled into the matching zone.
+func (c *AWSCloud) GetInstanceLabels(nodeName string) (map[string]string, error) {
+	instance, err := c.getInstanceByNodeName(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	az := ""
+	if instance.Placement != nil {
+		az = orEmpty(instance.Placement.AvailabilityZone)
+	}
+	if az == "" {
+		return nil, fmt.Errorf("instance did not have AZ information: %q", orEmpty(instance.InstanceId))
+	}
+
+	labels := make(map[string]string)
+	labels[unversioned.LabelZoneFailureDomain] = az
+	region, err := azToRegion(az)
+	if err != nil {
+		return nil, err
+	}
+	labels[unversioned.LabelZoneRegion] = region
+
+	return labels, nil
+}
+
+// Implements Volumes.DiskIsAttached
+func (c *AWSCloud) DiskIsAttached(diskName string, instanceName string) (bool, error) {
+	attached, err := c.DisksAreAttached([]string{diskName}, instanceName)
+	if err != nil {
+		return false, err
+	}
+
+	return attached[diskName], nil
+}
+
+// Implements Volumes.DisksAreAttached
+func (c *AWSCloud) DisksAreAttached(diskNames []string, instanceName string) (map[string]bool, error) {
+	attached := make(map[string]bool)
+	idToDiskName := make(map[string]string)
+	for _, diskName := range diskNames {
+		disk, err := newAWSDisk(c, diskName)
+		if err != nil {
+			return nil, err
+		}
+		attached[diskName] = false
+		idToDiskName[disk.awsID] = diskName
+	}
+
+	awsInstance, err := c.getAwsInstance(instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	// We query the EC2 API directly rather than trusting the local
+	// deviceMappings cache, so out-of-band detaches are detected.
+	info, err := awsInstance.getInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, blockDevice := range info.BlockDeviceMappings {
+		if blockDevice.Ebs == nil {
+			continue
+		}
+		if diskName, found := idToDiskName[orEmpty(blockDevice.Ebs.VolumeId)]; found {
+			attached[diskName] = true
+		}
+	}
+
+	return attached, nil
+}
+
+// InstanceLifecycleInfo describes how an instance is provisioned and placed,
+// so node registration can label and taint nodes appropriately (e.g. only
+// schedule tolerant workloads on spot instances).
+type InstanceLifecycleInfo struct {
+	// Lifecycle is "spot" for spot instances and "on-demand" otherwise
+	Lifecycle string
+	// PlacementGroup is the placement group the instance belongs to, or
+	// empty if none
+	PlacementGroup string
+	// Tenancy is the instance's tenancy, e.g. "default" or "dedicated"
+	Tenancy string
+}
+
+// InstanceLifecycle returns the lifecycle, placement group and tenancy of the
+// instance backing the named node
+func (s *AWSCloud) InstanceLifecycle(nodeName string) (*InstanceLifecycleInfo, error) {
+	instance, err := s.getInstanceByNodeName(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &InstanceLifecycleInfo{Lifecycle: "on-demand"}
+	// EC2 only sets InstanceLifecycle for spot instances
+	if orEmpty(instance.InstanceLifecycle) == ec2.InstanceLifecycleTypeSpot {
+		info.Lifecycle = ec2.InstanceLifecycleTypeSpot
+	}
+	if instance.Placement != nil {
+		info.PlacementGroup = orEmpty(instance.Placement.GroupName)
+		info.Tenancy = orEmpty(instance.Placement.Tenancy)
+	}
+	return info, nil
+}
+
+// How often we poll the metadata service for the spot termination notice
+const spotTerminationPollInterval = 5 * time.Second
+
+// The metadata key that appears roughly two minutes before EC2 reclaims a
+// spot instance
+const spotTerminationMetadataKey = "spot/termination-time"
+
+// WatchSpotTermination polls the metadata service for the spot two-minute
+// termination notice and calls handler with the advertised termination time
+// when one appears, so the caller can start draining the node. It blocks
+// until a notice has been handled or stop is closed, so it should be run in
+// its own goroutine; only spot instances ever receive the notice.
+func (s *AWSCloud) WatchSpotTermination(handler func(terminationTime time.Time), stop <-chan struct{}) {
+	for {
+		value, err := s.metadata.GetMetadata(spotTerminationMetadataKey)
+		if err != nil {
+			// The normal response on an instance that is not (yet) marked
+			// for termination is a 404
+			glog.V(4).Infof("No spot termination notice: %v", err)
+		} else if value != "" {
+			terminationTime, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+			if err != nil {
+				glog.Warningf("Could not parse spot termination time %q; assuming two minutes: %v", value, err)
+				terminationTime = time.Now().Add(2 * time.Minute)
+			}
+			glog.Warningf("Spot termination notice received; instance will be reclaimed at %s", terminationTime)
+			handler(terminationTime)
+			return
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(spotTerminationPollInterval):
+		}
+	}
+}
+
+// InstanceStatusEvent describes a scheduled event for an instance, e.g. a
+// pending reboot or hardware retirement.
+type InstanceStatusEvent struct {
+	// Code identifies the kind of event, e.g. "system-reboot" or
+	// "instance-retirement"
+	Code string
+	// Description is the human-readable description of the event
+	Description string
+	// NotBefore is the earliest scheduled start time of the event
+	NotBefore time.Time
+}
+
+// InstanceStatusInfo summarizes the EC2 status checks and scheduled events
+// for an instance, so the node controller can proactively mark nodes with
+// failed status checks or imminent maintenance as unhealthy.
+type InstanceStatusInfo struct {
+	// SystemStatus is the system status check summary, e.g. "ok" or
+	// "impaired"
+	SystemStatus string
+	// InstanceStatus is the instance status check summary
+	InstanceStatus string
+	// Events holds any scheduled events for the instance
+	Events []InstanceStatusEvent
+}
+
+// InstanceStatus returns the EC2 status checks and scheduled events of the
+// instance backing the named node
+func (s *AWSCloud) InstanceStatus(nodeName string) (*InstanceStatusInfo, error) {
+	instance, err := s.getInstanceByNodeName(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	instanceID := orEmpty(instance.InstanceId)
+
+	request := &ec2.DescribeInstanceStatusInput{
+		InstanceIds: []*string{instance.InstanceId},
+		// Also report instances that are not in the running state
+		IncludeAllInstances: aws.Bool(true),
+	}
+
+	statuses, err := s.ec2.DescribeInstanceStatus(request)
+	if err != nil {
+		return nil, fmt.Errorf("error describing instance status: %v", err)
+	}
+	if len(statuses) == 0 {
+		return nil, fmt.Errorf("no status found for instance: %s", instanceID)
+	}
+	if len(statuses) > 1 {
+		return nil, fmt.Errorf("multiple statuses found for instance: %s", instanceID)
+	}
+	status := statuses[0]
+
+	info := &InstanceStatusInfo{}
+	if status.SystemStatus != nil {
+		info.SystemStatus = orEmpty(status.SystemStatus.Status)
+	}
+	if status.InstanceStatus != nil {
+		info.InstanceStatus = orEmpty(status.InstanceStatus.Status)
+	}
+	for _, event := range status.Events {
+		e := InstanceStatusEvent{
+			Code:        orEmpty(event.Code),
+			Description: orEmpty(event.Description),
+		}
+		if event.NotBefore != nil {
+			e.NotBefore = *event.NotBefore
+		}
+		info.Events = append(info.Events, e)
+	}
+	return info, nil
+}
+
+// ConfigureNode applies the node's registered spec to the underlying
+// instance, making node registration on AWS self-configuring: the source/dest
+// check is disabled so the instance can carry pod traffic, the pod CIDR gets
+// a VPC route if one is missing, the instance is tagged to reflect
+// schedulability, and we warn if the instance has no IAM instance profile.
+func (s *AWSCloud) ConfigureNode(nodeName string, spec *api.NodeSpec) error {
+	instance, err := s.getInstanceByNodeName(nodeName)
+	if err != nil {
+		return err
+	}
+	instanceID := orEmpty(instance.InstanceId)
+
+	// Pods on this node have addresses the VPC does not know about, so the
+	// instance must accept traffic that is not addressed to itself
+	if err := s.configureInstanceSourceDestCheck(instanceID, false); err != nil {
+		return err
+	}
+
+	if spec.PodCIDR != "" {
+		if err := s.ensurePodCIDRRoute(nodeName, spec.PodCIDR); err != nil {
+			return err
+		}
+	}
+
+	unschedulable := "false"
+	if spec.Unschedulable {
+		unschedulable = "true"
+	}
+	if err := s.createTags(instanceID, map[string]string{TagNameKubernetesUnschedulable: unschedulable}); err != nil {
+		return err
+	}
+
+	if instance.IamInstanceProfile == nil {
+		glog.Warningf("Instance %s has no IAM instance profile; kubelet cloud API calls may fail", instanceID)
+	}
+
+	return nil
+}
+
+// Ensures there is a VPC route sending the node's pod CIDR to its instance
+func (s *AWSCloud) ensurePodCIDRRoute(nodeName string, podCIDR string) error {
+	clusterName := s.getClusterName()
+	routes, err := s.ListRoutes(clusterName)
+	if err != nil {
+		return err
+	}
+	for _, route := range routes {
+		if route.DestinationCIDR != podCIDR {
+			continue
+		}
+		if route.TargetInstance == nodeName {
+			return nil
+		}
+		// Another instance owns the CIDR; leave this for the route
+		// controller to reconcile rather than fighting over it here
+		return fmt.Errorf("pod CIDR %s for node %s is already routed to %s", podCIDR, nodeName, route.TargetInstance)
+	}
+	route := &cloudprovider.Route{TargetInstance: nodeName, DestinationCIDR: podCIDR}
+	return s.CreateRoute(clusterName, nodeName, route)
+}
+
+// ReleaseNode cleans up cloud resources still bound to a node that is being
+// removed from the cluster. EBS volumes carrying the cluster tag that are
+// still attached to the instance are detached, and the instance is
+// deregistered from every load balancer that still lists it. Without this,
+// scaling down leaves cluster volumes stuck on terminated instances and load
+// balancers fail health checks against instances that no longer exist. The
+// source/dest check that ConfigureNode disabled is also re-enabled, returning
+// the instance to the EC2 default for whatever it is used for next.
+func (s *AWSCloud) ReleaseNode(nodeName string) error {
+	instance, err := s.findInstanceByNodeName(nodeName)
+	if err != nil {
+		return err
+	}
+	if instance == nil {
+		// The instance is already gone; there is nothing to clean up
+		glog.V(2).Infof("Instance not found for node being released: %s", nodeName)
+		return nil
+	}
+	instanceID := orEmpty(instance.InstanceId)
+
+	if err := s.configureInstanceSourceDestCheck(instanceID, true); err != nil {
+		return err
+	}
+
+	if err := s.detachClusterVolumes(instance); err != nil {
+		return err
+	}
+	return s.deregisterInstanceFromLoadBalancers(instanceID)
+}
+
+// Detaches every EBS volume carrying the cluster tag that is attached to the
+// instance. Volumes without the cluster tag (e.g. the root volume) are left
+// alone.
+func (s *AWSCloud) detachClusterVolumes(instance *ec2.Instance) error {
+	instanceID := orEmpty(instance.InstanceId)
+
+	if s.getClusterName() == "" {
+		// Without a cluster tag we cannot tell our volumes apart from e.g.
+		// the root volume, so do not detach anything
+		glog.Warning("No cluster tag configured; not detaching volumes from instance: ", instanceID)
+		return nil
+	}
+
+	filters := []*ec2.Filter{newEc2Filter("attachment.instance-id", instanceID)}
+	request := &ec2.DescribeVolumesInput{Filters: s.addFilters(filters)}
+	volumes, err := s.ec2.DescribeVolumes(request)
+	if err != nil {
+		return fmt.Errorf("error listing volumes attached to instance %s: %v", instanceID, err)
+	}
+
+	for _, volume := range volumes {
+		volumeID := orEmpty(volume.VolumeId)
+		request := &ec2.DetachVolumeInput{
+			InstanceId: aws.String(instanceID),
+			VolumeId:   aws.String(volumeID),
+		}
+		if _, err := s.ec2.DetachVolume(request); err != nil {
+			return fmt.Errorf("error detaching volume %s from instance %s: %v", volumeID, instanceID, err)
+		}
+		glog.V(2).Infof("Detached volume %s from instance %s", volumeID, instanceID)
+	}
+	return nil
+}
+
+// Removes the instance from every load balancer that still has it registered
+func (s *AWSCloud) deregisterInstanceFromLoadBalancers(instanceID string) error {
+	response, err := s.elb.DescribeLoadBalancers(&elb.DescribeLoadBalancersInput{})
+	if err != nil {
+		return fmt.Errorf("error listing load balancers: %v", err)
+	}
+
+	for _, lb := range response.LoadBalancerDescriptions {
+		registered := false
+		for _, lbInstance := range lb.Instances {
+			if orEmpty(lbInstance.InstanceId) == instanceID {
+				registered = true
+				break
+			}
+		}
+		if !registered {
+			continue
+		}
+
+		request := &elb.DeregisterInstancesFromLoadBalancerInput{}
+		request.LoadBalancerName = lb.LoadBalancerName
+		request.Instances = []*elb.Instance{{InstanceId: aws.String(instanceID)}}
+		if _, err := s.elb.DeregisterInstancesFromLoadBalancer(request); err != nil {
+			return fmt.Errorf("error deregistering instance %s from load balancer %s: %v", instanceID, orEmpty(lb.LoadBalancerName), err)
+		}
+		glog.V(1).Infof("Instance %s removed from load-balancer %s", instanceID, orEmpty(lb.LoadBalancerName))
+	}
+	return nil
+}
+
 // Gets the current load balancer state
 func (s *AWSCloud) describeLoadBalancer(name string) (*elb.LoadBalancerDescription, error) {
 	request := &elb.DescribeLoadBalancersInput{}
@@ -1469,8 +2503,14 @@ func (s *AWSCloud) describeLoadBalancer(name string) (*elb.LoadBalancerDescripti
 	return ret, nil
 }
 
-// Retrieves instance's vpc id from metadata
+// Returns the configured vpc id, falling back to the instance's metadata.
+// Returns an empty string (without error) on EC2-Classic, where instances
+// have no VPC.
 func (self *AWSCloud) findVPCID() (string, error) {
+	if self.cfg.Global.VPCID != "" {
+		return self.cfg.Global.VPCID, nil
+	}
+
 	macs, err := self.metadata.GetMetadata("network/interfaces/macs/")
 	if err != nil {
 		return "", fmt.Errorf("Could not list interfaces of the instance", err)
@@ -1488,7 +2528,9 @@ func (self *AWSCloud) findVPCID() (string, error) {
 		}
 		return vpcID, nil
 	}
-	return "", fmt.Errorf("Could not find VPC ID in instance metadata")
+
+	// No interface has a vpc-id entry: we are running on EC2-Classic
+	return "", nil
 }
 
 // Retrieves the specified security group from the AWS API, or returns nil if not found
@@ -1576,8 +2618,18 @@ func ipPermissionExists(newPermission, existing *ec2.IpPermission, compareGroupU
 }
 
 func isEqualUserGroupPair(l, r *ec2.UserIdGroupPair, compareGroupUserIDs bool) bool {
-	glog.V(2).Infof("Comparing %v to %v", *l.GroupId, *r.GroupId)
-	if isEqualStringPointer(l.GroupId, r.GroupId) {
+	glog.V(2).Infof("Comparing %v to %v", l, r)
+
+	// EC2-Classic rules reference groups by name; compare on the name when
+	// either side has no group id
+	equal := false
+	if l.GroupId != nil && r.GroupId != nil {
+		equal = isEqualStringPointer(l.GroupId, r.GroupId)
+	} else {
+		equal = isEqualStringPointer(l.GroupName, r.GroupName)
+	}
+
+	if equal {
 		if compareGroupUserIDs {
 			if isEqualStringPointer(l.UserId, r.UserId) {
 				return true
@@ -1646,6 +2698,93 @@ func (s *AWSCloud) ensureSecurityGroupIngress(securityGroupId string, addPermiss
 	return true, nil
 }
 
+// Makes sure the security group ingress is exactly the specified permissions:
+// permissions the group is missing are authorized and permissions the group
+// carries but that are no longer wanted are revoked, so stale rules do not
+// linger when a service's ports or source ranges change.
+// Returns true if and only if changes were made
+// The security group must already exist
+func (s *AWSCloud) setSecurityGroupIngress(securityGroupId string, desiredPermissions []*ec2.IpPermission) (bool, error) {
+	group, err := s.findSecurityGroup(securityGroupId)
+	if err != nil {
+		glog.Warning("Error retrieving security group", err)
+		return false, err
+	}
+
+	if group == nil {
+		return false, fmt.Errorf("security group not found: %s", securityGroupId)
+	}
+
+	glog.V(2).Infof("Existing security group ingress: %s %v", securityGroupId, group.IpPermissions)
+
+	adds := []*ec2.IpPermission{}
+	for _, permission := range desiredPermissions {
+		hasUserID := false
+		for i := range permission.UserIdGroupPairs {
+			if permission.UserIdGroupPairs[i].UserId != nil {
+				hasUserID = true
+			}
+		}
+
+		found := false
+		for _, groupPermission := range group.IpPermissions {
+			if ipPermissionExists(permission, groupPermission, hasUserID) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			adds = append(adds, permission)
+		}
+	}
+
+	removes := []*ec2.IpPermission{}
+	for _, groupPermission := range group.IpPermissions {
+		found := false
+		for _, permission := range desiredPermissions {
+			if ipPermissionExists(groupPermission, permission, false) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			removes = append(removes, groupPermission)
+		}
+	}
+
+	changed := false
+	if len(removes) > 0 {
+		glog.V(2).Infof("Removing security group ingress: %s %v", securityGroupId, removes)
+
+		request := &ec2.RevokeSecurityGroupIngressInput{}
+		request.GroupId = &securityGroupId
+		request.IpPermissions = removes
+		_, err = s.ec2.RevokeSecurityGroupIngress(request)
+		if err != nil {
+			glog.Warning("Error revoking security group ingress", err)
+			return false, err
+		}
+		changed = true
+	}
+	if len(adds) > 0 {
+		glog.V(2).Infof("Adding security group ingress: %s %v", securityGroupId, adds)
+
+		request := &ec2.AuthorizeSecurityGroupIngressInput{}
+		request.GroupId = &securityGroupId
+		request.IpPermissions = adds
+		_, err = s.ec2.AuthorizeSecurityGroupIngress(request)
+		if err != nil {
+			glog.Warning("Error authorizing security group ingress", err)
+			return false, err
+		}
+		changed = true
+	}
+
+	return changed, nil
+}
+
 // Makes sure the security group no longer includes the specified permissions
 // Returns true if and only if changes were made
 // If the security group no longer exists, will return (false, nil)
@@ -1851,16 +2990,19 @@ func (s *AWSCloud) createTags(resourceID string, tags map[string]string) error {
 	}
 }
 
-func (s *AWSCloud) listSubnetIDsinVPC(vpcId string) ([]string, error) {
-
-	subnetIds := []string{}
+// Lists the subnets (one per availability zone) an ELB in the VPC should be
+// attached to. If availabilityZones is non-empty, only subnets in those
+// zones are returned.
+func (s *AWSCloud) listSubnetIDsinVPC(vpcId string, availabilityZones []string) ([]string, error) {
+	includeZones := map[string]bool{}
+	for _, zone := range availabilityZones {
+		includeZones[zone] = true
+	}
 
 	request := &ec2.DescribeSubnetsInput{}
 	filters := []*ec2.Filter{}
 	filters = append(filters, newEc2Filter("vpc-id", vpcId))
 	// Note, this will only return subnets tagged with the cluster identifier for this Kubernetes cluster.
-	// In the case where an AZ has public & private subnets per AWS best practices, the deployment should ensure
-	// only the public subnet (where the ELB will go) is so tagged.
 	filters = s.addFilters(filters)
 	request.Filters = filters
 
@@ -1870,23 +3012,182 @@ func (s *AWSCloud) listSubnetIDsinVPC(vpcId string) ([]string, error) {
 		return nil, err
 	}
 
-	availabilityZones := sets.NewString()
+	// If any subnet carries the ELB role tag, only consider tagged
+	// subnets; this lets deployments with several subnets per AZ (or
+	// private subnets) mark the ones the ELB should go into.
+	tagged := []*ec2.Subnet{}
+	for _, subnet := range subnets {
+		if _, found := findTag(subnet.Tags, TagNameSubnetELB); found {
+			tagged = append(tagged, subnet)
+		}
+	}
+	if len(tagged) != 0 {
+		subnets = tagged
+	}
+
+	// ELBs require at most one subnet per AZ. When an AZ has several
+	// candidates we prefer a public subnet (one whose route table has a
+	// route to an internet gateway), since an internet-facing ELB in a
+	// private subnet is unreachable; ties break on the lowest subnet ID
+	// so repeated syncs pick the same subnet.
+	rrequest := &ec2.DescribeRouteTablesInput{}
+	rrequest.Filters = []*ec2.Filter{newEc2Filter("vpc-id", vpcId)}
+	routeTables, err := s.ec2.DescribeRouteTables(rrequest)
+	if err != nil {
+		glog.Error("Error describing route tables: ", err)
+		return nil, err
+	}
+
+	subnetsByAZ := map[string]*ec2.Subnet{}
 	for _, subnet := range subnets {
 		az := orEmpty(subnet.AvailabilityZone)
-		id := orEmpty(subnet.SubnetId)
-		if availabilityZones.Has(az) {
-			glog.Warning("Found multiple subnets per AZ '", az, "', ignoring subnet '", id, "'")
+		existing := subnetsByAZ[az]
+		if existing == nil {
+			subnetsByAZ[az] = subnet
+			continue
+		}
+		subnetIsPublic := isSubnetPublic(routeTables, subnet)
+		existingIsPublic := isSubnetPublic(routeTables, existing)
+		if subnetIsPublic != existingIsPublic {
+			if subnetIsPublic {
+				subnetsByAZ[az] = subnet
+			}
 			continue
 		}
-		subnetIds = append(subnetIds, id)
-		availabilityZones.Insert(az)
+		if orEmpty(subnet.SubnetId) < orEmpty(existing.SubnetId) {
+			subnetsByAZ[az] = subnet
+		}
 	}
 
+	subnetIds := []string{}
+	for az := range subnetsByAZ {
+		if len(includeZones) != 0 && !includeZones[az] {
+			continue
+		}
+		subnetIds = append(subnetIds, orEmpty(subnetsByAZ[az].SubnetId))
+	}
+	if len(subnetIds) == 0 && len(subnetsByAZ) != 0 {
+		// None of the requested zones has a usable subnet; fall back to all
+		// subnets rather than detaching the load balancer from everything
+		glog.Warningf("No subnets found in availability zones %v; using all cluster subnets", availabilityZones)
+		for az := range subnetsByAZ {
+			subnetIds = append(subnetIds, orEmpty(subnetsByAZ[az].SubnetId))
+		}
+	}
+	sort.Strings(subnetIds)
+
 	return subnetIds, nil
 }
 
+// findTag returns the value of the named tag, if present
+func findTag(tags []*ec2.Tag, key string) (string, bool) {
+	for _, tag := range tags {
+		if orEmpty(tag.Key) == key {
+			return orEmpty(tag.Value), true
+		}
+	}
+	return "", false
+}
+
+// isSubnetPublic reports whether the subnet's route table (its explicit
+// association, or the VPC's main table) routes to an internet gateway
+func isSubnetPublic(routeTables []*ec2.RouteTable, subnet *ec2.Subnet) bool {
+	var table *ec2.RouteTable
+	for _, rt := range routeTables {
+		for _, assoc := range rt.Associations {
+			if orEmpty(assoc.SubnetId) == orEmpty(subnet.SubnetId) {
+				table = rt
+			}
+		}
+	}
+	if table == nil {
+		// No explicit association; the main route table applies
+		for _, rt := range routeTables {
+			for _, assoc := range rt.Associations {
+				if assoc.Main != nil && *assoc.Main {
+					table = rt
+				}
+			}
+		}
+	}
+	if table == nil {
+		return false
+	}
+	for _, route := range table.Routes {
+		if strings.HasPrefix(orEmpty(route.GatewayId), "igw-") {
+			return true
+		}
+	}
+	return false
+}
+
 // EnsureLoadBalancer implements LoadBalancer.EnsureLoadBalancer
 // TODO(justinsb) It is weird that these take a region.  I suspect it won't work cross-region anyway.
+// portTerminatesTLS reports whether a service port is covered by the
+// ssl-ports annotation: an empty or "*" spec covers every port, and
+// otherwise the spec is a comma-separated list of port names and
+// numbers.
+func portTerminatesTLS(port *api.ServicePort, spec string) bool {
+	if spec == "" || spec == "*" {
+		return true
+	}
+	for _, element := range strings.Split(spec, ",") {
+		element = strings.TrimSpace(element)
+		if element == port.Name || element == strconv.Itoa(port.Port) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildListener constructs the ELB listener for one service port. When
+// the service carries a certificate annotation covering the port, the
+// listener terminates TLS: clients speak HTTPS (for HTTP backends) or
+// SSL, and the ELB speaks the plain backend protocol to the instances.
+func buildListener(port *api.ServicePort, annotations cloudprovider.ServiceAnnotation) *elb.Listener {
+	instancePort := int64(port.NodePort)
+	loadBalancerPort := int64(port.Port)
+	instanceProtocol := strings.ToLower(string(port.Protocol))
+	protocol := instanceProtocol
+
+	listener := &elb.Listener{}
+	listener.InstancePort = &instancePort
+	listener.LoadBalancerPort = &loadBalancerPort
+
+	certificateARN := annotations[ServiceAnnotationLoadBalancerCertificate]
+	if certificateARN != "" && portTerminatesTLS(port, annotations[ServiceAnnotationLoadBalancerSSLPorts]) {
+		switch annotations[ServiceAnnotationLoadBalancerBackendProtocol] {
+		case "http", "https":
+			instanceProtocol = annotations[ServiceAnnotationLoadBalancerBackendProtocol]
+			protocol = "https"
+		default:
+			protocol = "ssl"
+		}
+		listener.SSLCertificateId = aws.String(certificateARN)
+	}
+	listener.Protocol = &protocol
+	listener.InstanceProtocol = &instanceProtocol
+	return listener
+}
+
+// Returns the sorted set of availability zones the instances are in
+func instanceAvailabilityZones(instances []*ec2.Instance) []string {
+	zones := map[string]bool{}
+	for _, instance := range instances {
+		if instance.Placement != nil {
+			zones[orEmpty(instance.Placement.AvailabilityZone)] = true
+		}
+	}
+	delete(zones, "")
+
+	zoneList := []string{}
+	for zone := range zones {
+		zoneList = append(zoneList, zone)
+	}
+	sort.Strings(zoneList)
+	return zoneList
+}
+
 func (s *AWSCloud) EnsureLoadBalancer(name, region string, publicIP net.IP, ports []*api.ServicePort, hosts []string, serviceName types.NamespacedName, affinity api.ServiceAffinity, annotations cloudprovider.ServiceAnnotation) (*api.LoadBalancerStatus, error) {
 	glog.V(2).Infof("EnsureLoadBalancer(%v, %v, %v, %v, %v, %v, %v)", name, region, publicIP, ports, hosts, serviceName, annotations)
 
@@ -1924,24 +3225,61 @@ func (s *AWSCloud) EnsureLoadBalancer(name, region string, publicIP net.IP, port
 		return nil, err
 	}
 
-	// Construct list of configured subnets
-	subnetIDs, err := s.listSubnetIDsinVPC(vpcId)
-	if err != nil {
-		glog.Error("Error listing subnets in VPC", err)
-		return nil, err
-	}
-
-	// Create a security group for the load balancer
-	var securityGroupID string
-	{
-		sgName := "k8s-elb-" + name
-		sgDescription := fmt.Sprintf("Security group for Kubernetes ELB %s (%v)", name, serviceName)
-		securityGroupID, err = s.ensureSecurityGroup(sgName, sgDescription, vpcId)
+	// On EC2-Classic there is no VPC: the load balancer is attached to
+	// availability zones rather than subnets, and it gets the Amazon-managed
+	// ELB security group rather than one we create.
+	// We only attach the load balancer to zones that actually have
+	// registered instances; an ELB routes traffic to all its zones, so a
+	// zone without backends would just fail health checks.
+	instanceZones := instanceAvailabilityZones(instances)
+
+	var subnetIDs []string
+	var securityGroupIDs []string
+	var availabilityZones []string
+	if vpcId == "" {
+		// EC2-Classic: attach the load balancer to the availability zones
+		// that have registered instances
+		availabilityZones = instanceZones
+		if len(availabilityZones) == 0 {
+			availabilityZones = []string{s.availabilityZone}
+		}
+	} else {
+		// Construct list of configured subnets, restricted to the zones
+		// that have registered instances
+		subnetIDs, err = s.listSubnetIDsinVPC(vpcId, instanceZones)
 		if err != nil {
-			glog.Error("Error creating load balancer security group: ", err)
+			glog.Error("Error listing subnets in VPC", err)
 			return nil, err
 		}
 
+		// Find or create a security group for the load balancer
+		var securityGroupID string
+
+		// Organizations that do not allow the controller to create
+		// security groups can point us at a preexisting one instead;
+		// we then skip creation and tagging but still reconcile the
+		// ingress rules below.
+		securityGroupID = s.cfg.Global.ElbSecurityGroup
+		if value, ok := annotations.GetValue(ServiceAnnotationLoadBalancerSecurityGroup); ok {
+			securityGroupID = value
+		}
+		if securityGroupID == "" {
+			sgName := "k8s-elb-" + name
+			sgDescription := fmt.Sprintf("Security group for Kubernetes ELB %s (%v)", name, serviceName)
+			securityGroupID, err = s.ensureSecurityGroup(sgName, sgDescription, vpcId)
+			if err != nil {
+				glog.Error("Error creating load balancer security group: ", err)
+				return nil, err
+			}
+
+			// ensureSecurityGroup applies the cluster tags; also record
+			// which service the group belongs to.
+			err = s.createTags(securityGroupID, map[string]string{TagNameKubernetesService: serviceName.String()})
+			if err != nil {
+				return nil, fmt.Errorf("error tagging load balancer security group: %v", err)
+			}
+		}
+
 		permissions := []*ec2.IpPermission{}
 		for _, port := range ports {
 			portInt64 := int64(port.Port)
@@ -1956,35 +3294,47 @@ func (s *AWSCloud) EnsureLoadBalancer(name, region string, publicIP net.IP, port
 
 			permissions = append(permissions, permission)
 		}
-		_, err = s.ensureSecurityGroupIngress(securityGroupID, permissions)
+		// Make the rule set match exactly, so rules for ports the
+		// service no longer uses are revoked
+		_, err = s.setSecurityGroupIngress(securityGroupID, permissions)
 		if err != nil {
 			return nil, err
 		}
+
+		securityGroupIDs = []string{securityGroupID}
 	}
-	securityGroupIDs := []string{securityGroupID}
 
 	// Figure out what mappings we want on the load balancer
+	certificateARN := annotations[ServiceAnnotationLoadBalancerCertificate]
+	if annotations[ServiceAnnotationLoadBalancerSSLPorts] != "" && certificateARN == "" {
+		return nil, fmt.Errorf("annotation %s requires a certificate in annotation %s",
+			ServiceAnnotationLoadBalancerSSLPorts, ServiceAnnotationLoadBalancerCertificate)
+	}
 	listeners := []*elb.Listener{}
 	for _, port := range ports {
 		if port.NodePort == 0 {
 			glog.Errorf("Ignoring port without NodePort defined: %v", port)
 			continue
 		}
-		instancePort := int64(port.NodePort)
-		loadBalancerPort := int64(port.Port)
-		protocol := strings.ToLower(string(port.Protocol))
-
-		listener := &elb.Listener{}
-		listener.InstancePort = &instancePort
-		listener.LoadBalancerPort = &loadBalancerPort
-		listener.Protocol = &protocol
-		listener.InstanceProtocol = &protocol
+		listeners = append(listeners, buildListener(port, annotations))
+	}
 
-		listeners = append(listeners, listener)
+	crossZone := s.cfg.Global.ElbCrossZoneLoadBalancing
+	if value, ok := annotations.GetValue(ServiceAnnotationLoadBalancerCrossZone); ok {
+		crossZone, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s must be true or false, got %q",
+				ServiceAnnotationLoadBalancerCrossZone, value)
+		}
 	}
 
 	// Build the load balancer itself
-	loadBalancer, err := s.ensureLoadBalancer(serviceName, name, listeners, subnetIDs, securityGroupIDs)
+	loadBalancer, err := s.ensureLoadBalancer(serviceName, name, listeners, subnetIDs, availabilityZones, securityGroupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.ensureCrossZoneLoadBalancing(loadBalancer, crossZone)
 	if err != nil {
 		return nil, err
 	}
@@ -2045,31 +3395,51 @@ func toStatus(lb *elb.LoadBalancerDescription) *api.LoadBalancerStatus {
 	return status
 }
 
-// Returns the first security group for an instance, or nil
-// We only create instances with one security group, so we warn if there are multiple or none
-func findSecurityGroupForInstance(instance *ec2.Instance) *string {
-	var securityGroupId *string
+// Returns the security group to manage on the instance, so the load
+// balancer's security group can be allowed to reach it. Instances normally
+// have exactly one security group; when there are several we prefer the one
+// carrying our cluster tag, since that is the group the cluster manages.
+func (s *AWSCloud) findSecurityGroupForInstance(instance *ec2.Instance) (*string, error) {
+	var securityGroupIds []*string
 	for _, securityGroup := range instance.SecurityGroups {
 		if securityGroup == nil || securityGroup.GroupId == nil {
 			// Not expected, but avoid panic
 			glog.Warning("Unexpected empty security group for instance: ", orEmpty(instance.InstanceId))
 			continue
 		}
+		securityGroupIds = append(securityGroupIds, securityGroup.GroupId)
+	}
 
-		if securityGroupId != nil {
-			// We create instances with one SG
-			glog.Warningf("Multiple security groups found for instance (%s); will use first group (%s)", orEmpty(instance.InstanceId), *securityGroupId)
-			continue
-		} else {
-			securityGroupId = securityGroup.GroupId
+	if len(securityGroupIds) > 1 {
+		describeRequest := &ec2.DescribeSecurityGroupsInput{
+			GroupIds: securityGroupIds,
 		}
+		groups, err := s.ec2.DescribeSecurityGroups(describeRequest)
+		if err != nil {
+			return nil, fmt.Errorf("error querying security groups for instance %s: %v", orEmpty(instance.InstanceId), err)
+		}
+
+		clusterName := s.getClusterName()
+		for _, group := range groups {
+			for _, tag := range group.Tags {
+				if orEmpty(tag.Key) == TagNameKubernetesCluster && orEmpty(tag.Value) == clusterName {
+					return group.GroupId, nil
+				}
+			}
+		}
+		glog.Warningf("Multiple security groups found for instance (%s) and none has the cluster tag; will use first group (%s)", orEmpty(instance.InstanceId), *securityGroupIds[0])
+	}
+
+	var securityGroupId *string
+	if len(securityGroupIds) != 0 {
+		securityGroupId = securityGroupIds[0]
 	}
 
 	if securityGroupId == nil {
 		glog.Warning("No security group found for instance ", orEmpty(instance.InstanceId))
 	}
 
-	return securityGroupId
+	return securityGroupId, nil
 }
 
 // Open security group ingress rules on the instances so that the load balancer can talk to them
@@ -2079,7 +3449,9 @@ func (s *AWSCloud) updateInstanceSecurityGroupsForLoadBalancer(lb *elb.LoadBalan
 		return nil
 	}
 
-	// Determine the load balancer security group id
+	// Determine the load balancer security group id. On EC2-Classic the
+	// load balancer has no security group of its own; ingress rules instead
+	// reference the Amazon-owned source security group by name.
 	loadBalancerSecurityGroupId := ""
 	for _, securityGroup := range lb.SecurityGroups {
 		if isNilOrEmpty(securityGroup) {
@@ -2091,14 +3463,27 @@ func (s *AWSCloud) updateInstanceSecurityGroupsForLoadBalancer(lb *elb.LoadBalan
 		}
 		loadBalancerSecurityGroupId = *securityGroup
 	}
-	if loadBalancerSecurityGroupId == "" {
+
+	sourceGroup := &ec2.UserIdGroupPair{}
+	sourceGroupFilter := ""
+	sourceGroupFilterValue := ""
+	if loadBalancerSecurityGroupId != "" {
+		sourceGroup.GroupId = &loadBalancerSecurityGroupId
+		sourceGroupFilter = "ip-permission.group-id"
+		sourceGroupFilterValue = loadBalancerSecurityGroupId
+	} else if lb.SourceSecurityGroup != nil && !isNilOrEmpty(lb.SourceSecurityGroup.GroupName) {
+		sourceGroup.GroupName = lb.SourceSecurityGroup.GroupName
+		sourceGroup.UserId = lb.SourceSecurityGroup.OwnerAlias
+		sourceGroupFilter = "ip-permission.group-name"
+		sourceGroupFilterValue = *lb.SourceSecurityGroup.GroupName
+	} else {
 		return fmt.Errorf("Could not determine security group for load balancer: %s", orEmpty(lb.LoadBalancerName))
 	}
 
 	// Get the actual list of groups that allow ingress from the load-balancer
 	describeRequest := &ec2.DescribeSecurityGroupsInput{}
 	filters := []*ec2.Filter{}
-	filters = append(filters, newEc2Filter("ip-permission.group-id", loadBalancerSecurityGroupId))
+	filters = append(filters, newEc2Filter(sourceGroupFilter, sourceGroupFilterValue))
 	describeRequest.Filters = s.addFilters(filters)
 	actualGroups, err := s.ec2.DescribeSecurityGroups(describeRequest)
 	if err != nil {
@@ -2115,7 +3500,10 @@ func (s *AWSCloud) updateInstanceSecurityGroupsForLoadBalancer(lb *elb.LoadBalan
 
 	// Scan instances for groups we want open
 	for _, instance := range allInstances {
-		securityGroupId := findSecurityGroupForInstance(instance)
+		securityGroupId, err := s.findSecurityGroupForInstance(instance)
+		if err != nil {
+			return err
+		}
 		if isNilOrEmpty(securityGroupId) {
 			glog.Warning("Ignoring instance without security group: ", orEmpty(instance.InstanceId))
 			continue
@@ -2145,18 +3533,16 @@ func (s *AWSCloud) updateInstanceSecurityGroupsForLoadBalancer(lb *elb.LoadBalan
 
 	for instanceSecurityGroupId, add := range instanceSecurityGroupIds {
 		if add {
-			glog.V(2).Infof("Adding rule for traffic from the load balancer (%s) to instances (%s)", loadBalancerSecurityGroupId, instanceSecurityGroupId)
+			glog.V(2).Infof("Adding rule for traffic from the load balancer (%s) to instances (%s)", sourceGroupFilterValue, instanceSecurityGroupId)
 		} else {
-			glog.V(2).Infof("Removing rule for traffic from the load balancer (%s) to instance (%s)", loadBalancerSecurityGroupId, instanceSecurityGroupId)
+			glog.V(2).Infof("Removing rule for traffic from the load balancer (%s) to instance (%s)", sourceGroupFilterValue, instanceSecurityGroupId)
 		}
-		sourceGroupId := &ec2.UserIdGroupPair{}
-		sourceGroupId.GroupId = &loadBalancerSecurityGroupId
 
 		allProtocols := "-1"
 
 		permission := &ec2.IpPermission{}
 		permission.IpProtocol = &allProtocols
-		permission.UserIdGroupPairs = []*ec2.UserIdGroupPair{sourceGroupId}
+		permission.UserIdGroupPairs = []*ec2.UserIdGroupPair{sourceGroup}
 
 		permissions := []*ec2.IpPermission{permission}
 
@@ -2225,13 +3611,27 @@ func (s *AWSCloud) EnsureLoadBalancerDeleted(name, region string) error {
 		// Note that this is annoying: the load balancer disappears from the API immediately, but it is still
 		// deleting in the background.  We get a DependencyViolation until the load balancer has deleted itself
 
-		// Collect the security groups to delete
+		// Collect the security groups to delete. Only groups carrying our
+		// cluster tag are ours to delete; a preexisting group attached via
+		// configuration or annotation is left alone.
 		securityGroupIDs := map[string]struct{}{}
 		for _, securityGroupID := range lb.SecurityGroups {
 			if isNilOrEmpty(securityGroupID) {
 				glog.Warning("Ignoring empty security group in ", name)
 				continue
 			}
+
+			request := &ec2.DescribeSecurityGroupsInput{}
+			request.Filters = s.addFilters([]*ec2.Filter{newEc2Filter("group-id", *securityGroupID)})
+			groups, err := s.ec2.DescribeSecurityGroups(request)
+			if err != nil {
+				return fmt.Errorf("error querying load balancer security group (%s): %v", *securityGroupID, err)
+			}
+			if len(groups) == 0 {
+				glog.V(2).Infof("Not deleting security group %s; it does not carry the cluster tag", *securityGroupID)
+				continue
+			}
+
 			securityGroupIDs[*securityGroupID] = struct{}{}
 		}
 
@@ -2298,7 +3698,28 @@ func (s *AWSCloud) UpdateLoadBalancer(name, region string, hosts []string) error
 
 	err = s.ensureLoadBalancerInstances(orEmpty(lb.LoadBalancerName), lb.Instances, instances)
 	if err != nil {
-		return nil
+		return err
+	}
+
+	// Keep the load balancer attached only to the zones/subnets that have
+	// registered instances
+	availabilityZones := instanceAvailabilityZones(instances)
+	if len(availabilityZones) != 0 {
+		var subnetIDs []string
+		if len(lb.Subnets) != 0 {
+			vpcId, err := s.findVPCID()
+			if err != nil {
+				return err
+			}
+			subnetIDs, err = s.listSubnetIDsinVPC(vpcId, availabilityZones)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = s.ensureLoadBalancerAttachments(lb, subnetIDs, availabilityZones)
+		if err != nil {
+			return err
+		}
 	}
 
 	err = s.updateInstanceSecurityGroupsForLoadBalancer(lb, instances)
@@ -2358,8 +3779,12 @@ func (a *AWSCloud) getInstancesByIDs(instanceIDs []*string) (map[string]*ec2.Ins
 func (a *AWSCloud) getInstancesByNodeNames(nodeNames []string) ([]*ec2.Instance, error) {
 	names := aws.StringSlice(nodeNames)
 
+	filterName := "private-dns-name"
+	if a.cfg.Global.NodeNameStrategy == NodeNameStrategyNameTag {
+		filterName = "tag:Name"
+	}
 	nodeNameFilter := &ec2.Filter{
-		Name:   aws.String("private-dns-name"),
+		Name:   aws.String(filterName),
 		Values: names,
 	}
 
@@ -2387,11 +3812,25 @@ func (a *AWSCloud) getInstancesByNodeNames(nodeNames []string) ([]*ec2.Instance,
 	return instances, nil
 }
 
+// nodeNameFilter returns the EC2 filter that matches instances with the
+// given node name, following the configured resolution strategy. Node names
+// that are instance IDs (as found in a node's providerID) always match on
+// the instance ID.
+func (a *AWSCloud) nodeNameFilter(nodeName string) *ec2.Filter {
+	if strings.HasPrefix(nodeName, "i-") {
+		return newEc2Filter("instance-id", nodeName)
+	}
+	if a.cfg.Global.NodeNameStrategy == NodeNameStrategyNameTag {
+		return newEc2Filter("tag:Name", nodeName)
+	}
+	return newEc2Filter("private-dns-name", nodeName)
+}
+
 // Returns the instance with the specified node name
 // Returns nil if it does not exist
 func (a *AWSCloud) findInstanceByNodeName(nodeName string) (*ec2.Instance, error) {
 	filters := []*ec2.Filter{
-		newEc2Filter("private-dns-name", nodeName),
+		a.nodeNameFilter(nodeName),
 		newEc2Filter("instance-state-name", "running"),
 	}
 	filters = a.addFilters(filters)
@@ -0,0 +1,488 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/golang/glog"
+)
+
+// TestClusterId is the cluster id the self instance created by
+// NewFakeAWSServices is tagged with.
+const TestClusterId = "clusterid.test"
+
+// FakeAWSServices is an in-memory implementation of AWSServices, so unit
+// tests in other components (volume plugins, service controller) can test
+// against the AWS provider without mocks of their own. Instances, volumes
+// and failures are configurable; operations the fake does not implement
+// panic, so tests fail loudly rather than silently passing.
+type FakeAWSServices struct {
+	availabilityZone        string
+	instances               []*ec2.Instance
+	volumes                 []*ec2.Volume
+	instanceId              string
+	privateDnsName          string
+	networkInterfacesMacs   []string
+	networkInterfacesVpcIDs []string
+	internalIP              string
+	externalIP              string
+	spotTerminationTime     string
+
+	ec2      *FakeEC2
+	elb      *FakeELB
+	asg      *FakeASG
+	metadata *FakeMetadata
+}
+
+// NewFakeAWSServices returns a FakeAWSServices with a single self instance
+// in us-east-1a, tagged as belonging to TestClusterId.
+func NewFakeAWSServices() *FakeAWSServices {
+	s := &FakeAWSServices{}
+	s.availabilityZone = "us-east-1a"
+	s.ec2 = &FakeEC2{aws: s}
+	s.elb = &FakeELB{aws: s}
+	s.asg = &FakeASG{aws: s}
+	s.metadata = &FakeMetadata{aws: s}
+
+	s.networkInterfacesMacs = []string{"aa:bb:cc:dd:ee:00", "aa:bb:cc:dd:ee:01"}
+	s.networkInterfacesVpcIDs = []string{"vpc-mac0", "vpc-mac1"}
+
+	s.instanceId = "i-self"
+	s.privateDnsName = "ip-172-20-0-100.ec2.internal"
+	s.internalIP = "192.168.0.1"
+	s.externalIP = "1.2.3.4"
+	var selfInstance ec2.Instance
+	selfInstance.InstanceId = &s.instanceId
+	selfInstance.PrivateDnsName = &s.privateDnsName
+	s.instances = []*ec2.Instance{&selfInstance}
+
+	var tag ec2.Tag
+	tag.Key = aws.String(TagNameKubernetesCluster)
+	tag.Value = aws.String(TestClusterId)
+	selfInstance.Tags = []*ec2.Tag{&tag}
+
+	return s
+}
+
+// WithAz sets the availability zone the fake reports and returns the fake,
+// for chaining.
+func (s *FakeAWSServices) WithAz(az string) *FakeAWSServices {
+	s.availabilityZone = az
+	return s
+}
+
+// WithInstances replaces the set of instances the fake knows about and
+// returns the fake, for chaining.
+func (s *FakeAWSServices) WithInstances(instances []*ec2.Instance) *FakeAWSServices {
+	s.instances = instances
+	return s
+}
+
+// WithVolumes replaces the set of volumes the fake knows about and returns
+// the fake, for chaining.
+func (s *FakeAWSServices) WithVolumes(volumes []*ec2.Volume) *FakeAWSServices {
+	s.volumes = volumes
+	return s
+}
+
+func (s *FakeAWSServices) Compute(region string) (EC2, error) {
+	return s.ec2, nil
+}
+
+func (s *FakeAWSServices) LoadBalancing(region string) (ELB, error) {
+	return s.elb, nil
+}
+
+func (s *FakeAWSServices) Autoscaling(region string) (ASG, error) {
+	return s.asg, nil
+}
+
+func (s *FakeAWSServices) Metadata() (EC2Metadata, error) {
+	return s.metadata, nil
+}
+
+// FakeEC2 is a fake implementation of the EC2 interface, backed by the
+// state in a FakeAWSServices.
+type FakeEC2 struct {
+	aws                      *FakeAWSServices
+	Subnets                  []*ec2.Subnet
+	DescribeSubnetsInput     *ec2.DescribeSubnetsInput
+	RouteTables              []*ec2.RouteTable
+	DescribeRouteTablesInput *ec2.DescribeRouteTablesInput
+	InstanceStatuses         []*ec2.InstanceStatus
+
+	// Errors injects failures: when an operation name (e.g.
+	// "DescribeInstances") has an entry, that operation returns the error
+	// instead of executing.
+	Errors map[string]error
+}
+
+func (self *FakeEC2) injectedError(operation string) error {
+	if self.Errors == nil {
+		return nil
+	}
+	return self.Errors[operation]
+}
+
+func contains(haystack []*string, needle string) bool {
+	for _, s := range haystack {
+		// (deliberately panic if s == nil)
+		if needle == *s {
+			return true
+		}
+	}
+	return false
+}
+
+func instanceMatchesFilter(instance *ec2.Instance, filter *ec2.Filter) bool {
+	name := *filter.Name
+	if name == "private-dns-name" {
+		if instance.PrivateDnsName == nil {
+			return false
+		}
+		return contains(filter.Values, *instance.PrivateDnsName)
+	}
+
+	if name == "instance-state-name" {
+		return contains(filter.Values, *instance.State.Name)
+	}
+
+	if name == "tag-key" {
+		for _, tag := range instance.Tags {
+			if contains(filter.Values, *tag.Key) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if strings.HasPrefix(name, "tag:") {
+		tagName := name[len("tag:"):]
+		for _, tag := range instance.Tags {
+			if *tag.Key == tagName {
+				return containsPattern(filter.Values, *tag.Value)
+			}
+		}
+		return false
+	}
+
+	panic("Unknown filter name: " + name)
+}
+
+// containsPattern is like contains, but the haystack holds EC2 filter
+// patterns, where '*' matches any sequence of characters and '?' any
+// single character.
+func containsPattern(haystack []*string, needle string) bool {
+	for _, s := range haystack {
+		pattern := regexp.QuoteMeta(*s)
+		pattern = strings.Replace(pattern, `\*`, ".*", -1)
+		pattern = strings.Replace(pattern, `\?`, ".", -1)
+		matched, err := regexp.MatchString("^"+pattern+"$", needle)
+		if err != nil {
+			panic(err)
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *FakeEC2) DescribeInstances(request *ec2.DescribeInstancesInput) ([]*ec2.Instance, error) {
+	if err := self.injectedError("DescribeInstances"); err != nil {
+		return nil, err
+	}
+
+	matches := []*ec2.Instance{}
+	for _, instance := range self.aws.instances {
+		if request.InstanceIds != nil {
+			if instance.InstanceId == nil {
+				glog.Warning("Instance with no instance id: ", instance)
+				continue
+			}
+
+			found := false
+			for _, instanceId := range request.InstanceIds {
+				if *instanceId == *instance.InstanceId {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		if request.Filters != nil {
+			allMatch := true
+			for _, filter := range request.Filters {
+				if !instanceMatchesFilter(instance, filter) {
+					allMatch = false
+					break
+				}
+			}
+			if !allMatch {
+				continue
+			}
+		}
+		matches = append(matches, instance)
+	}
+
+	return matches, nil
+}
+
+func (self *FakeEC2) DescribeInstanceStatus(request *ec2.DescribeInstanceStatusInput) ([]*ec2.InstanceStatus, error) {
+	if err := self.injectedError("DescribeInstanceStatus"); err != nil {
+		return nil, err
+	}
+
+	matches := []*ec2.InstanceStatus{}
+	for _, status := range self.InstanceStatuses {
+		if request.InstanceIds != nil {
+			if status.InstanceId == nil {
+				glog.Warning("Instance status with no instance id: ", status)
+				continue
+			}
+			if !contains(request.InstanceIds, *status.InstanceId) {
+				continue
+			}
+		}
+		matches = append(matches, status)
+	}
+
+	return matches, nil
+}
+
+// FakeMetadata is a fake implementation of the EC2 metadata service,
+// backed by the state in a FakeAWSServices.
+type FakeMetadata struct {
+	aws *FakeAWSServices
+}
+
+func (self *FakeMetadata) GetMetadata(key string) (string, error) {
+	networkInterfacesPrefix := "network/interfaces/macs/"
+	if key == "placement/availability-zone" {
+		return self.aws.availabilityZone, nil
+	} else if key == "instance-id" {
+		return self.aws.instanceId, nil
+	} else if key == "local-hostname" {
+		return self.aws.privateDnsName, nil
+	} else if key == "local-ipv4" {
+		return self.aws.internalIP, nil
+	} else if key == "public-ipv4" {
+		return self.aws.externalIP, nil
+	} else if key == "spot/termination-time" {
+		return self.aws.spotTerminationTime, nil
+	} else if strings.HasPrefix(key, networkInterfacesPrefix) {
+		if key == networkInterfacesPrefix {
+			return strings.Join(self.aws.networkInterfacesMacs, "/\n") + "/\n", nil
+		} else {
+			keySplit := strings.Split(key, "/")
+			macParam := keySplit[3]
+			if len(keySplit) == 5 && keySplit[4] == "vpc-id" {
+				for i, macElem := range self.aws.networkInterfacesMacs {
+					if macParam == macElem {
+						return self.aws.networkInterfacesVpcIDs[i], nil
+					}
+				}
+			}
+			return "", nil
+		}
+	} else {
+		return "", nil
+	}
+}
+
+func (ec2 *FakeEC2) AttachVolume(request *ec2.AttachVolumeInput) (resp *ec2.VolumeAttachment, err error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeEC2) DetachVolume(request *ec2.DetachVolumeInput) (resp *ec2.VolumeAttachment, err error) {
+	panic("Not implemented")
+}
+
+func (self *FakeEC2) DescribeVolumes(request *ec2.DescribeVolumesInput) ([]*ec2.Volume, error) {
+	if err := self.injectedError("DescribeVolumes"); err != nil {
+		return nil, err
+	}
+
+	matches := []*ec2.Volume{}
+	for _, volume := range self.aws.volumes {
+		if request.VolumeIds != nil {
+			if volume.VolumeId == nil {
+				glog.Warning("Volume with no volume id: ", volume)
+				continue
+			}
+			if !contains(request.VolumeIds, *volume.VolumeId) {
+				continue
+			}
+		}
+		matches = append(matches, volume)
+	}
+
+	return matches, nil
+}
+
+func (ec2 *FakeEC2) CreateVolume(request *ec2.CreateVolumeInput) (resp *ec2.Volume, err error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeEC2) DeleteVolume(request *ec2.DeleteVolumeInput) (resp *ec2.DeleteVolumeOutput, err error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeEC2) CreateSnapshot(request *ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeEC2) DescribeSecurityGroups(request *ec2.DescribeSecurityGroupsInput) ([]*ec2.SecurityGroup, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeEC2) CreateSecurityGroup(*ec2.CreateSecurityGroupInput) (*ec2.CreateSecurityGroupOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeEC2) DeleteSecurityGroup(*ec2.DeleteSecurityGroupInput) (*ec2.DeleteSecurityGroupOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeEC2) AuthorizeSecurityGroupIngress(*ec2.AuthorizeSecurityGroupIngressInput) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeEC2) RevokeSecurityGroupIngress(*ec2.RevokeSecurityGroupIngressInput) (*ec2.RevokeSecurityGroupIngressOutput, error) {
+	panic("Not implemented")
+}
+
+func (self *FakeEC2) DescribeSubnets(request *ec2.DescribeSubnetsInput) ([]*ec2.Subnet, error) {
+	if err := self.injectedError("DescribeSubnets"); err != nil {
+		return nil, err
+	}
+	self.DescribeSubnetsInput = request
+	return self.Subnets, nil
+}
+
+func (ec2 *FakeEC2) CreateTags(*ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+	panic("Not implemented")
+}
+
+func (s *FakeEC2) DescribeRouteTables(request *ec2.DescribeRouteTablesInput) ([]*ec2.RouteTable, error) {
+	if err := s.injectedError("DescribeRouteTables"); err != nil {
+		return nil, err
+	}
+	s.DescribeRouteTablesInput = request
+	return s.RouteTables, nil
+}
+
+func (s *FakeEC2) CreateRoute(request *ec2.CreateRouteInput) (*ec2.CreateRouteOutput, error) {
+	panic("Not implemented")
+}
+
+func (s *FakeEC2) DeleteRoute(request *ec2.DeleteRouteInput) (*ec2.DeleteRouteOutput, error) {
+	panic("Not implemented")
+}
+
+func (s *FakeEC2) ModifyInstanceAttribute(request *ec2.ModifyInstanceAttributeInput) (*ec2.ModifyInstanceAttributeOutput, error) {
+	panic("Not implemented")
+}
+
+// FakeELB is a fake implementation of the ELB interface.
+type FakeELB struct {
+	aws *FakeAWSServices
+}
+
+func (ec2 *FakeELB) CreateLoadBalancer(*elb.CreateLoadBalancerInput) (*elb.CreateLoadBalancerOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeELB) DeleteLoadBalancer(*elb.DeleteLoadBalancerInput) (*elb.DeleteLoadBalancerOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeELB) DescribeLoadBalancers(*elb.DescribeLoadBalancersInput) (*elb.DescribeLoadBalancersOutput, error) {
+	panic("Not implemented")
+}
+func (ec2 *FakeELB) RegisterInstancesWithLoadBalancer(*elb.RegisterInstancesWithLoadBalancerInput) (*elb.RegisterInstancesWithLoadBalancerOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeELB) DeregisterInstancesFromLoadBalancer(*elb.DeregisterInstancesFromLoadBalancerInput) (*elb.DeregisterInstancesFromLoadBalancerOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeELB) DetachLoadBalancerFromSubnets(*elb.DetachLoadBalancerFromSubnetsInput) (*elb.DetachLoadBalancerFromSubnetsOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeELB) AttachLoadBalancerToSubnets(*elb.AttachLoadBalancerToSubnetsInput) (*elb.AttachLoadBalancerToSubnetsOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeELB) EnableAvailabilityZonesForLoadBalancer(*elb.EnableAvailabilityZonesForLoadBalancerInput) (*elb.EnableAvailabilityZonesForLoadBalancerOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeELB) DisableAvailabilityZonesForLoadBalancer(*elb.DisableAvailabilityZonesForLoadBalancerInput) (*elb.DisableAvailabilityZonesForLoadBalancerOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeELB) CreateLoadBalancerListeners(*elb.CreateLoadBalancerListenersInput) (*elb.CreateLoadBalancerListenersOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeELB) DeleteLoadBalancerListeners(*elb.DeleteLoadBalancerListenersInput) (*elb.DeleteLoadBalancerListenersOutput, error) {
+	panic("Not implemented")
+}
+
+func (ec2 *FakeELB) ApplySecurityGroupsToLoadBalancer(*elb.ApplySecurityGroupsToLoadBalancerInput) (*elb.ApplySecurityGroupsToLoadBalancerOutput, error) {
+	panic("Not implemented")
+}
+
+func (elb *FakeELB) ConfigureHealthCheck(*elb.ConfigureHealthCheckInput) (*elb.ConfigureHealthCheckOutput, error) {
+	panic("Not implemented")
+}
+
+func (self *FakeELB) AddTags(*elb.AddTagsInput) (*elb.AddTagsOutput, error) {
+	panic("Not implemented")
+}
+
+func (self *FakeELB) DescribeLoadBalancerAttributes(*elb.DescribeLoadBalancerAttributesInput) (*elb.DescribeLoadBalancerAttributesOutput, error) {
+	panic("Not implemented")
+}
+
+func (self *FakeELB) ModifyLoadBalancerAttributes(*elb.ModifyLoadBalancerAttributesInput) (*elb.ModifyLoadBalancerAttributesOutput, error) {
+	panic("Not implemented")
+}
+
+// FakeASG is a fake implementation of the ASG interface.
+type FakeASG struct {
+	aws *FakeAWSServices
+}
+
+func (a *FakeASG) UpdateAutoScalingGroup(*autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	panic("Not implemented")
+}
+
+func (a *FakeASG) DescribeAutoScalingGroups(*autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	panic("Not implemented")
+}
@@ -28,7 +28,7 @@ import (
 	"k8s.io/kubernetes/pkg/util/sets"
 )
 
-func (s *AWSCloud) ensureLoadBalancer(namespacedName types.NamespacedName, name string, listeners []*elb.Listener, subnetIDs []string, securityGroupIDs []string) (*elb.LoadBalancerDescription, error) {
+func (s *AWSCloud) ensureLoadBalancer(namespacedName types.NamespacedName, name string, listeners []*elb.Listener, subnetIDs []string, availabilityZones []string, securityGroupIDs []string) (*elb.LoadBalancerDescription, error) {
 	loadBalancer, err := s.describeLoadBalancer(name)
 	if err != nil {
 		return nil, err
@@ -42,9 +42,15 @@ func (s *AWSCloud) ensureLoadBalancer(namespacedName types.NamespacedName, name
 
 		createRequest.Listeners = listeners
 
-		// We are supposed to specify one subnet per AZ.
-		// TODO: What happens if we have more than one subnet per AZ?
-		createRequest.Subnets = stringPointerArray(subnetIDs)
+		if len(subnetIDs) != 0 {
+			// We are supposed to specify one subnet per AZ.
+			// TODO: What happens if we have more than one subnet per AZ?
+			createRequest.Subnets = stringPointerArray(subnetIDs)
+		} else {
+			// EC2-Classic: there are no subnets, so the load balancer is
+			// attached directly to availability zones
+			createRequest.AvailabilityZones = stringPointerArray(availabilityZones)
+		}
 
 		createRequest.SecurityGroups = stringPointerArray(securityGroupIDs)
 
@@ -61,34 +67,27 @@ func (s *AWSCloud) ensureLoadBalancer(namespacedName types.NamespacedName, name
 		dirty = true
 	} else {
 		{
-			// Sync subnets
-			expected := sets.NewString(subnetIDs...)
-			actual := stringSetFromPointers(loadBalancer.Subnets)
-
-			additions := expected.Difference(actual)
-			removals := actual.Difference(expected)
-
-			if removals.Len() != 0 {
-				request := &elb.DetachLoadBalancerFromSubnetsInput{}
-				request.LoadBalancerName = aws.String(name)
-				request.Subnets = stringSetToPointers(removals)
-				glog.V(2).Info("Detaching load balancer from removed subnets")
-				_, err := s.elb.DetachLoadBalancerFromSubnets(request)
-				if err != nil {
-					return nil, fmt.Errorf("error detaching AWS loadbalancer from subnets: %v", err)
-				}
-				dirty = true
+			// Re-apply the tags on every sync. AddTags is idempotent and
+			// this recovers load balancers created before tagging (or
+			// whose AddTags call failed).
+			request := &elb.AddTagsInput{}
+			request.LoadBalancerNames = []*string{aws.String(name)}
+			request.Tags = []*elb.Tag{
+				{Key: aws.String(TagNameKubernetesCluster), Value: aws.String(s.getClusterName())},
+				{Key: aws.String(TagNameKubernetesService), Value: aws.String(namespacedName.String())},
+			}
+			_, err := s.elb.AddTags(request)
+			if err != nil {
+				return nil, fmt.Errorf("error tagging AWS loadbalancer: %v", err)
 			}
+		}
 
-			if additions.Len() != 0 {
-				request := &elb.AttachLoadBalancerToSubnetsInput{}
-				request.LoadBalancerName = aws.String(name)
-				request.Subnets = stringSetToPointers(additions)
-				glog.V(2).Info("Attaching load balancer to added subnets")
-				_, err := s.elb.AttachLoadBalancerToSubnets(request)
-				if err != nil {
-					return nil, fmt.Errorf("error attaching AWS loadbalancer to subnets: %v", err)
-				}
+		{
+			attachmentsDirty, err := s.ensureLoadBalancerAttachments(loadBalancer, subnetIDs, availabilityZones)
+			if err != nil {
+				return nil, err
+			}
+			if attachmentsDirty {
 				dirty = true
 			}
 		}
@@ -196,6 +195,113 @@ func (s *AWSCloud) ensureLoadBalancer(namespacedName types.NamespacedName, name
 	return loadBalancer, nil
 }
 
+// Makes sure the load balancer is attached to exactly the specified subnets
+// (in a VPC), or availability zones (EC2-Classic, where subnetIDs is empty).
+// Returns true if and only if changes were made.
+func (s *AWSCloud) ensureLoadBalancerAttachments(loadBalancer *elb.LoadBalancerDescription, subnetIDs []string, availabilityZones []string) (bool, error) {
+	name := orEmpty(loadBalancer.LoadBalancerName)
+	dirty := false
+
+	if len(subnetIDs) != 0 {
+		// Sync subnets
+		expected := sets.NewString(subnetIDs...)
+		actual := stringSetFromPointers(loadBalancer.Subnets)
+
+		additions := expected.Difference(actual)
+		removals := actual.Difference(expected)
+
+		// Attach before detach, so the load balancer is never attached to
+		// zero subnets
+		if additions.Len() != 0 {
+			request := &elb.AttachLoadBalancerToSubnetsInput{}
+			request.LoadBalancerName = aws.String(name)
+			request.Subnets = stringSetToPointers(additions)
+			glog.V(2).Info("Attaching load balancer to added subnets")
+			_, err := s.elb.AttachLoadBalancerToSubnets(request)
+			if err != nil {
+				return dirty, fmt.Errorf("error attaching AWS loadbalancer to subnets: %v", err)
+			}
+			dirty = true
+		}
+
+		if removals.Len() != 0 {
+			request := &elb.DetachLoadBalancerFromSubnetsInput{}
+			request.LoadBalancerName = aws.String(name)
+			request.Subnets = stringSetToPointers(removals)
+			glog.V(2).Info("Detaching load balancer from removed subnets")
+			_, err := s.elb.DetachLoadBalancerFromSubnets(request)
+			if err != nil {
+				return dirty, fmt.Errorf("error detaching AWS loadbalancer from subnets: %v", err)
+			}
+			dirty = true
+		}
+	} else if len(availabilityZones) != 0 {
+		// Sync availability zones (EC2-Classic)
+		expected := sets.NewString(availabilityZones...)
+		actual := stringSetFromPointers(loadBalancer.AvailabilityZones)
+
+		additions := expected.Difference(actual)
+		removals := actual.Difference(expected)
+
+		// Enable before disable, so the load balancer is never attached
+		// to zero zones
+		if additions.Len() != 0 {
+			request := &elb.EnableAvailabilityZonesForLoadBalancerInput{}
+			request.LoadBalancerName = aws.String(name)
+			request.AvailabilityZones = stringSetToPointers(additions)
+			glog.V(2).Info("Enabling added availability zones on load balancer")
+			_, err := s.elb.EnableAvailabilityZonesForLoadBalancer(request)
+			if err != nil {
+				return dirty, fmt.Errorf("error enabling AWS loadbalancer availability zones: %v", err)
+			}
+			dirty = true
+		}
+
+		if removals.Len() != 0 {
+			request := &elb.DisableAvailabilityZonesForLoadBalancerInput{}
+			request.LoadBalancerName = aws.String(name)
+			request.AvailabilityZones = stringSetToPointers(removals)
+			glog.V(2).Info("Disabling removed availability zones on load balancer")
+			_, err := s.elb.DisableAvailabilityZonesForLoadBalancer(request)
+			if err != nil {
+				return dirty, fmt.Errorf("error disabling AWS loadbalancer availability zones: %v", err)
+			}
+			dirty = true
+		}
+	}
+
+	return dirty, nil
+}
+
+// Makes sure the cross-zone load balancing setting of an ELB matches
+// the configuration. The current attributes are read first so that
+// no-op syncs do not call ModifyLoadBalancerAttributes.
+func (s *AWSCloud) ensureCrossZoneLoadBalancing(loadBalancer *elb.LoadBalancerDescription, enabled bool) error {
+	describeRequest := &elb.DescribeLoadBalancerAttributesInput{}
+	describeRequest.LoadBalancerName = loadBalancer.LoadBalancerName
+	response, err := s.elb.DescribeLoadBalancerAttributes(describeRequest)
+	if err != nil {
+		return fmt.Errorf("error describing AWS loadbalancer attributes: %v", err)
+	}
+	attributes := response.LoadBalancerAttributes
+	if attributes != nil && attributes.CrossZoneLoadBalancing != nil &&
+		aws.BoolValue(attributes.CrossZoneLoadBalancing.Enabled) == enabled {
+		return nil
+	}
+
+	glog.V(2).Infof("Setting cross-zone load balancing to %t on %s", enabled, orEmpty(loadBalancer.LoadBalancerName))
+	request := &elb.ModifyLoadBalancerAttributesInput{}
+	request.LoadBalancerName = loadBalancer.LoadBalancerName
+	request.LoadBalancerAttributes = &elb.LoadBalancerAttributes{
+		CrossZoneLoadBalancing: &elb.CrossZoneLoadBalancing{Enabled: aws.Bool(enabled)},
+	}
+	_, err = s.elb.ModifyLoadBalancerAttributes(request)
+	if err != nil {
+		return fmt.Errorf("error modifying AWS loadbalancer attributes: %v", err)
+	}
+	return nil
+}
+
 // Makes sure that the health check for an ELB matches the configured listeners
 func (s *AWSCloud) ensureLoadBalancerHealthCheck(loadBalancer *elb.LoadBalancerDescription, listeners []*elb.Listener) error {
 	actual := loadBalancer.HealthCheck
@@ -20,12 +20,16 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/golang/glog"
 	"k8s.io/kubernetes/pkg/cloudprovider"
 )
 
-func (s *AWSCloud) findRouteTable(clusterName string) (*ec2.RouteTable, error) {
+// findRouteTables returns every route table tagged for the cluster. A VPC
+// with one subnet (and route table) per AZ returns several tables; pod routes
+// must be programmed into each of them so pods are reachable from every AZ.
+func (s *AWSCloud) findRouteTables(clusterName string) ([]*ec2.RouteTable, error) {
 	// This should be unnecessary (we already filter on TagNameKubernetesCluster,
 	// and something is broken if cluster name doesn't match, but anyway...
 	// TODO: All clouds should be cluster-aware by default
@@ -40,32 +44,29 @@ func (s *AWSCloud) findRouteTable(clusterName string) (*ec2.RouteTable, error) {
 	if len(tables) == 0 {
 		return nil, fmt.Errorf("unable to find route table for AWS cluster: %s", clusterName)
 	}
-
-	if len(tables) != 1 {
-		return nil, fmt.Errorf("found multiple matching AWS route tables for AWS cluster: %s", clusterName)
-	}
-	return tables[0], nil
+	return tables, nil
 }
 
 // ListRoutes implements Routes.ListRoutes
 // List all routes that match the filter
 func (s *AWSCloud) ListRoutes(clusterName string) ([]*cloudprovider.Route, error) {
-	table, err := s.findRouteTable(clusterName)
+	tables, err := s.findRouteTables(clusterName)
 	if err != nil {
 		return nil, err
 	}
 
-	var routes []*cloudprovider.Route
 	var instanceIDs []*string
 
-	for _, r := range table.Routes {
-		instanceID := orEmpty(r.InstanceId)
+	for _, table := range tables {
+		for _, r := range table.Routes {
+			instanceID := orEmpty(r.InstanceId)
 
-		if instanceID == "" {
-			continue
-		}
+			if instanceID == "" {
+				continue
+			}
 
-		instanceIDs = append(instanceIDs, &instanceID)
+			instanceIDs = append(instanceIDs, &instanceID)
+		}
 	}
 
 	instances, err := s.getInstancesByIDs(instanceIDs)
@@ -73,22 +74,28 @@ func (s *AWSCloud) ListRoutes(clusterName string) ([]*cloudprovider.Route, error
 		return nil, err
 	}
 
-	for _, r := range table.Routes {
-		instanceID := orEmpty(r.InstanceId)
-		destinationCIDR := orEmpty(r.DestinationCidrBlock)
-
-		if instanceID == "" || destinationCIDR == "" {
-			continue
-		}
-
-		instance, found := instances[instanceID]
-		if !found {
-			glog.Warningf("unable to find instance ID %s in the list of instances being routed to", instanceID)
-			continue
+	// The same route appears in every per-AZ table, so dedup by CIDR.
+	var routes []*cloudprovider.Route
+	seen := map[string]bool{}
+	for _, table := range tables {
+		for _, r := range table.Routes {
+			instanceID := orEmpty(r.InstanceId)
+			destinationCIDR := orEmpty(r.DestinationCidrBlock)
+
+			if instanceID == "" || destinationCIDR == "" || seen[destinationCIDR] {
+				continue
+			}
+
+			instance, found := instances[instanceID]
+			if !found {
+				glog.Warningf("unable to find instance ID %s in the list of instances being routed to", instanceID)
+				continue
+			}
+			seen[destinationCIDR] = true
+			instanceName := orEmpty(instance.PrivateDnsName)
+			routeName := clusterName + "-" + destinationCIDR
+			routes = append(routes, &cloudprovider.Route{Name: routeName, TargetInstance: instanceName, DestinationCIDR: destinationCIDR})
 		}
-		instanceName := orEmpty(instance.PrivateDnsName)
-		routeName := clusterName + "-" + destinationCIDR
-		routes = append(routes, &cloudprovider.Route{Name: routeName, TargetInstance: instanceName, DestinationCIDR: destinationCIDR})
 	}
 
 	return routes, nil
@@ -122,46 +129,60 @@ func (s *AWSCloud) CreateRoute(clusterName string, nameHint string, route *cloud
 		return err
 	}
 
-	table, err := s.findRouteTable(clusterName)
+	tables, err := s.findRouteTables(clusterName)
 	if err != nil {
 		return err
 	}
 
-	var deleteRoute *ec2.Route
-	for _, r := range table.Routes {
-		destinationCIDR := aws.StringValue(r.DestinationCidrBlock)
+	for _, table := range tables {
+		var deleteRoute *ec2.Route
+		for _, r := range table.Routes {
+			destinationCIDR := aws.StringValue(r.DestinationCidrBlock)
 
-		if destinationCIDR != route.DestinationCIDR {
-			continue
-		}
+			if destinationCIDR != route.DestinationCIDR {
+				continue
+			}
 
-		if aws.StringValue(r.State) == ec2.RouteStateBlackhole {
-			deleteRoute = r
+			if aws.StringValue(r.State) == ec2.RouteStateBlackhole {
+				deleteRoute = r
+			}
 		}
-	}
 
-	if deleteRoute != nil {
-		glog.Infof("deleting blackholed route: %s", aws.StringValue(deleteRoute.DestinationCidrBlock))
+		if deleteRoute != nil {
+			glog.Infof("deleting blackholed route: %s", aws.StringValue(deleteRoute.DestinationCidrBlock))
 
-		request := &ec2.DeleteRouteInput{}
-		request.DestinationCidrBlock = deleteRoute.DestinationCidrBlock
-		request.RouteTableId = table.RouteTableId
+			request := &ec2.DeleteRouteInput{}
+			request.DestinationCidrBlock = deleteRoute.DestinationCidrBlock
+			request.RouteTableId = table.RouteTableId
 
-		_, err = s.ec2.DeleteRoute(request)
-		if err != nil {
-			return fmt.Errorf("error deleting blackholed AWS route (%s): %v", deleteRoute.DestinationCidrBlock, err)
+			_, err = s.ec2.DeleteRoute(request)
+			if err != nil {
+				return fmt.Errorf("error deleting blackholed AWS route (%s): %v", deleteRoute.DestinationCidrBlock, err)
+			}
 		}
-	}
 
-	request := &ec2.CreateRouteInput{}
-	// TODO: use ClientToken for idempotency?
-	request.DestinationCidrBlock = aws.String(route.DestinationCIDR)
-	request.InstanceId = instance.InstanceId
-	request.RouteTableId = table.RouteTableId
+		request := &ec2.CreateRouteInput{}
+		// TODO: use ClientToken for idempotency?
+		request.DestinationCidrBlock = aws.String(route.DestinationCIDR)
+		request.InstanceId = instance.InstanceId
+		request.RouteTableId = table.RouteTableId
 
-	_, err = s.ec2.CreateRoute(request)
-	if err != nil {
-		return fmt.Errorf("error creating AWS route (%s): %v", route.DestinationCIDR, err)
+		_, err = s.ec2.CreateRoute(request)
+		if err != nil {
+			if awsError, ok := err.(awserr.Error); ok && awsError.Code() == "RouteAlreadyExists" {
+				// A retry after a partial failure already created the route
+				// in this table; that is fine.
+				continue
+			}
+			if awsError, ok := err.(awserr.Error); ok && awsError.Code() == "RouteLimitExceeded" {
+				// VPCs allow 50 routes per table by default; past that AWS
+				// rejects the call and the only fixes are an AWS limit
+				// increase or an overlay network, so say so explicitly.
+				return fmt.Errorf("error creating AWS route (%s): route table %s has reached the VPC route limit (50 by default); request a limit increase or use an overlay network: %v",
+					route.DestinationCIDR, orEmpty(table.RouteTableId), err)
+			}
+			return fmt.Errorf("error creating AWS route (%s): %v", route.DestinationCIDR, err)
+		}
 	}
 
 	return nil
@@ -170,18 +191,24 @@ func (s *AWSCloud) CreateRoute(clusterName string, nameHint string, route *cloud
 // DeleteRoute implements Routes.DeleteRoute
 // Delete the specified route
 func (s *AWSCloud) DeleteRoute(clusterName string, route *cloudprovider.Route) error {
-	table, err := s.findRouteTable(clusterName)
+	tables, err := s.findRouteTables(clusterName)
 	if err != nil {
 		return err
 	}
 
-	request := &ec2.DeleteRouteInput{}
-	request.DestinationCidrBlock = aws.String(route.DestinationCIDR)
-	request.RouteTableId = table.RouteTableId
+	for _, table := range tables {
+		request := &ec2.DeleteRouteInput{}
+		request.DestinationCidrBlock = aws.String(route.DestinationCIDR)
+		request.RouteTableId = table.RouteTableId
 
-	_, err = s.ec2.DeleteRoute(request)
-	if err != nil {
-		return fmt.Errorf("error deleting AWS route (%s): %v", route.DestinationCIDR, err)
+		_, err = s.ec2.DeleteRoute(request)
+		if err != nil {
+			if awsError, ok := err.(awserr.Error); ok && awsError.Code() == "InvalidRoute.NotFound" {
+				// Another table may never have held this route; that is fine.
+				continue
+			}
+			return fmt.Errorf("error deleting AWS route (%s): %v", route.DestinationCIDR, err)
+		}
 	}
 
 	return nil
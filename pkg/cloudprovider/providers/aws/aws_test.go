@@ -22,19 +22,16 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/elb"
 
-	"github.com/aws/aws-sdk-go/service/autoscaling"
-	"github.com/golang/glog"
 	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
 	"k8s.io/kubernetes/pkg/types"
 )
 
-const TestClusterId = "clusterid.test"
-
 func TestReadAWSCloudConfig(t *testing.T) {
 	tests := []struct {
 		name string
@@ -67,7 +64,7 @@ func TestReadAWSCloudConfig(t *testing.T) {
 		},
 		{
 			"No zone in config, metadata does not have zone",
-			strings.NewReader("[global]\n"), NewFakeAWSServices().withAz(""),
+			strings.NewReader("[global]\n"), NewFakeAWSServices().WithAz(""),
 			true, "",
 		},
 		{
@@ -105,76 +102,6 @@ func TestReadAWSCloudConfig(t *testing.T) {
 	}
 }
 
-type FakeAWSServices struct {
-	availabilityZone        string
-	instances               []*ec2.Instance
-	instanceId              string
-	privateDnsName          string
-	networkInterfacesMacs   []string
-	networkInterfacesVpcIDs []string
-	internalIP              string
-	externalIP              string
-
-	ec2      *FakeEC2
-	elb      *FakeELB
-	asg      *FakeASG
-	metadata *FakeMetadata
-}
-
-func NewFakeAWSServices() *FakeAWSServices {
-	s := &FakeAWSServices{}
-	s.availabilityZone = "us-east-1a"
-	s.ec2 = &FakeEC2{aws: s}
-	s.elb = &FakeELB{aws: s}
-	s.asg = &FakeASG{aws: s}
-	s.metadata = &FakeMetadata{aws: s}
-
-	s.networkInterfacesMacs = []string{"aa:bb:cc:dd:ee:00", "aa:bb:cc:dd:ee:01"}
-	s.networkInterfacesVpcIDs = []string{"vpc-mac0", "vpc-mac1"}
-
-	s.instanceId = "i-self"
-	s.privateDnsName = "ip-172-20-0-100.ec2.internal"
-	s.internalIP = "192.168.0.1"
-	s.externalIP = "1.2.3.4"
-	var selfInstance ec2.Instance
-	selfInstance.InstanceId = &s.instanceId
-	selfInstance.PrivateDnsName = &s.privateDnsName
-	s.instances = []*ec2.Instance{&selfInstance}
-
-	var tag ec2.Tag
-	tag.Key = aws.String(TagNameKubernetesCluster)
-	tag.Value = aws.String(TestClusterId)
-	selfInstance.Tags = []*ec2.Tag{&tag}
-
-	return s
-}
-
-func (s *FakeAWSServices) withAz(az string) *FakeAWSServices {
-	s.availabilityZone = az
-	return s
-}
-
-func (s *FakeAWSServices) withInstances(instances []*ec2.Instance) *FakeAWSServices {
-	s.instances = instances
-	return s
-}
-
-func (s *FakeAWSServices) Compute(region string) (EC2, error) {
-	return s.ec2, nil
-}
-
-func (s *FakeAWSServices) LoadBalancing(region string) (ELB, error) {
-	return s.elb, nil
-}
-
-func (s *FakeAWSServices) Autoscaling(region string) (ASG, error) {
-	return s.asg, nil
-}
-
-func (s *FakeAWSServices) Metadata() (EC2Metadata, error) {
-	return s.metadata, nil
-}
-
 func TestFilterTags(t *testing.T) {
 	awsServices := NewFakeAWSServices()
 	c, err := newAWSCloud(strings.NewReader("[global]"), awsServices)
@@ -205,7 +132,7 @@ func TestNewAWSCloud(t *testing.T) {
 	}{
 		{
 			"No config reader",
-			nil, NewFakeAWSServices().withAz(""),
+			nil, NewFakeAWSServices().WithAz(""),
 			true, "",
 		},
 		{
@@ -228,7 +155,7 @@ func TestNewAWSCloud(t *testing.T) {
 		{
 			"No zone in config or metadata",
 			strings.NewReader("[global]\n"),
-			NewFakeAWSServices().withAz(""),
+			NewFakeAWSServices().WithAz(""),
 			true, "",
 		},
 	}
@@ -251,260 +178,23 @@ func TestNewAWSCloud(t *testing.T) {
 	}
 }
 
-type FakeEC2 struct {
-	aws                  *FakeAWSServices
-	Subnets              []*ec2.Subnet
-	DescribeSubnetsInput *ec2.DescribeSubnetsInput
-}
-
-func contains(haystack []*string, needle string) bool {
-	for _, s := range haystack {
-		// (deliberately panic if s == nil)
-		if needle == *s {
-			return true
-		}
-	}
-	return false
-}
-
-func instanceMatchesFilter(instance *ec2.Instance, filter *ec2.Filter) bool {
-	name := *filter.Name
-	if name == "private-dns-name" {
-		if instance.PrivateDnsName == nil {
-			return false
-		}
-		return contains(filter.Values, *instance.PrivateDnsName)
-	}
-
-	if name == "instance-state-name" {
-		return contains(filter.Values, *instance.State.Name)
-	}
-
-	if name == "tag:"+TagNameKubernetesCluster {
-		for _, tag := range instance.Tags {
-			if *tag.Key == TagNameKubernetesCluster {
-				return contains(filter.Values, *tag.Value)
-			}
-		}
-		return false
-	}
-
-	panic("Unknown filter name: " + name)
-}
-
-func (self *FakeEC2) DescribeInstances(request *ec2.DescribeInstancesInput) ([]*ec2.Instance, error) {
-	matches := []*ec2.Instance{}
-	for _, instance := range self.aws.instances {
-		if request.InstanceIds != nil {
-			if instance.InstanceId == nil {
-				glog.Warning("Instance with no instance id: ", instance)
-				continue
-			}
-
-			found := false
-			for _, instanceId := range request.InstanceIds {
-				if *instanceId == *instance.InstanceId {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		}
-		if request.Filters != nil {
-			allMatch := true
-			for _, filter := range request.Filters {
-				if !instanceMatchesFilter(instance, filter) {
-					allMatch = false
-					break
-				}
-			}
-			if !allMatch {
-				continue
-			}
-		}
-		matches = append(matches, instance)
-	}
-
-	return matches, nil
-}
-
-type FakeMetadata struct {
-	aws *FakeAWSServices
-}
-
-func (self *FakeMetadata) GetMetadata(key string) (string, error) {
-	networkInterfacesPrefix := "network/interfaces/macs/"
-	if key == "placement/availability-zone" {
-		return self.aws.availabilityZone, nil
-	} else if key == "instance-id" {
-		return self.aws.instanceId, nil
-	} else if key == "local-hostname" {
-		return self.aws.privateDnsName, nil
-	} else if key == "local-ipv4" {
-		return self.aws.internalIP, nil
-	} else if key == "public-ipv4" {
-		return self.aws.externalIP, nil
-	} else if strings.HasPrefix(key, networkInterfacesPrefix) {
-		if key == networkInterfacesPrefix {
-			return strings.Join(self.aws.networkInterfacesMacs, "/\n") + "/\n", nil
-		} else {
-			keySplit := strings.Split(key, "/")
-			macParam := keySplit[3]
-			if len(keySplit) == 5 && keySplit[4] == "vpc-id" {
-				for i, macElem := range self.aws.networkInterfacesMacs {
-					if macParam == macElem {
-						return self.aws.networkInterfacesVpcIDs[i], nil
-					}
-				}
-			}
-			return "", nil
-		}
-	} else {
-		return "", nil
-	}
-}
-
-func (ec2 *FakeEC2) AttachVolume(request *ec2.AttachVolumeInput) (resp *ec2.VolumeAttachment, err error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeEC2) DetachVolume(request *ec2.DetachVolumeInput) (resp *ec2.VolumeAttachment, err error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeEC2) DescribeVolumes(request *ec2.DescribeVolumesInput) ([]*ec2.Volume, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeEC2) CreateVolume(request *ec2.CreateVolumeInput) (resp *ec2.Volume, err error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeEC2) DeleteVolume(request *ec2.DeleteVolumeInput) (resp *ec2.DeleteVolumeOutput, err error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeEC2) DescribeSecurityGroups(request *ec2.DescribeSecurityGroupsInput) ([]*ec2.SecurityGroup, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeEC2) CreateSecurityGroup(*ec2.CreateSecurityGroupInput) (*ec2.CreateSecurityGroupOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeEC2) DeleteSecurityGroup(*ec2.DeleteSecurityGroupInput) (*ec2.DeleteSecurityGroupOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeEC2) AuthorizeSecurityGroupIngress(*ec2.AuthorizeSecurityGroupIngressInput) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeEC2) RevokeSecurityGroupIngress(*ec2.RevokeSecurityGroupIngressInput) (*ec2.RevokeSecurityGroupIngressOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeEC2) DescribeSubnets(request *ec2.DescribeSubnetsInput) ([]*ec2.Subnet, error) {
-	ec2.DescribeSubnetsInput = request
-	return ec2.Subnets, nil
-}
-
-func (ec2 *FakeEC2) CreateTags(*ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
-	panic("Not implemented")
-}
-
-func (s *FakeEC2) DescribeRouteTables(request *ec2.DescribeRouteTablesInput) ([]*ec2.RouteTable, error) {
-	panic("Not implemented")
-}
-
-func (s *FakeEC2) CreateRoute(request *ec2.CreateRouteInput) (*ec2.CreateRouteOutput, error) {
-	panic("Not implemented")
-}
-
-func (s *FakeEC2) DeleteRoute(request *ec2.DeleteRouteInput) (*ec2.DeleteRouteOutput, error) {
-	panic("Not implemented")
-}
-
-func (s *FakeEC2) ModifyInstanceAttribute(request *ec2.ModifyInstanceAttributeInput) (*ec2.ModifyInstanceAttributeOutput, error) {
-	panic("Not implemented")
-}
-
-type FakeELB struct {
-	aws *FakeAWSServices
-}
-
-func (ec2 *FakeELB) CreateLoadBalancer(*elb.CreateLoadBalancerInput) (*elb.CreateLoadBalancerOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeELB) DeleteLoadBalancer(*elb.DeleteLoadBalancerInput) (*elb.DeleteLoadBalancerOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeELB) DescribeLoadBalancers(*elb.DescribeLoadBalancersInput) (*elb.DescribeLoadBalancersOutput, error) {
-	panic("Not implemented")
-}
-func (ec2 *FakeELB) RegisterInstancesWithLoadBalancer(*elb.RegisterInstancesWithLoadBalancerInput) (*elb.RegisterInstancesWithLoadBalancerOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeELB) DeregisterInstancesFromLoadBalancer(*elb.DeregisterInstancesFromLoadBalancerInput) (*elb.DeregisterInstancesFromLoadBalancerOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeELB) DetachLoadBalancerFromSubnets(*elb.DetachLoadBalancerFromSubnetsInput) (*elb.DetachLoadBalancerFromSubnetsOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeELB) AttachLoadBalancerToSubnets(*elb.AttachLoadBalancerToSubnetsInput) (*elb.AttachLoadBalancerToSubnetsOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeELB) CreateLoadBalancerListeners(*elb.CreateLoadBalancerListenersInput) (*elb.CreateLoadBalancerListenersOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeELB) DeleteLoadBalancerListeners(*elb.DeleteLoadBalancerListenersInput) (*elb.DeleteLoadBalancerListenersOutput, error) {
-	panic("Not implemented")
-}
-
-func (ec2 *FakeELB) ApplySecurityGroupsToLoadBalancer(*elb.ApplySecurityGroupsToLoadBalancerInput) (*elb.ApplySecurityGroupsToLoadBalancerOutput, error) {
-	panic("Not implemented")
-}
-
-func (elb *FakeELB) ConfigureHealthCheck(*elb.ConfigureHealthCheckInput) (*elb.ConfigureHealthCheckOutput, error) {
-	panic("Not implemented")
-}
-
-type FakeASG struct {
-	aws *FakeAWSServices
-}
-
-func (a *FakeASG) UpdateAutoScalingGroup(*autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
-	panic("Not implemented")
-}
-
-func (a *FakeASG) DescribeAutoScalingGroups(*autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
-	panic("Not implemented")
-}
-
 func mockInstancesResp(instances []*ec2.Instance) (*AWSCloud, *FakeAWSServices) {
-	awsServices := NewFakeAWSServices().withInstances(instances)
+	awsServices := NewFakeAWSServices().WithInstances(instances)
 	return &AWSCloud{
 		ec2:              awsServices.ec2,
 		availabilityZone: awsServices.availabilityZone,
 		metadata:         &FakeMetadata{aws: awsServices},
+		cfg:              &AWSCloudConfig{},
 	}, awsServices
 }
 
 func mockAvailabilityZone(region string, availabilityZone string) *AWSCloud {
-	awsServices := NewFakeAWSServices().withAz(availabilityZone)
+	awsServices := NewFakeAWSServices().WithAz(availabilityZone)
 	return &AWSCloud{
 		ec2:              awsServices.ec2,
 		availabilityZone: awsServices.availabilityZone,
 		region:           region,
+		cfg:              &AWSCloudConfig{},
 	}
 }
 
@@ -788,7 +478,7 @@ func TestSubnetIDsinVPC(t *testing.T) {
 	subnets[2]["az"] = "af-south-1c"
 	awsServices.ec2.Subnets = constructSubnets(subnets)
 
-	result, err := c.listSubnetIDsinVPC(vpcID)
+	result, err := c.listSubnetIDsinVPC(vpcID, nil)
 	if err != nil {
 		t.Errorf("Error listing subnets: %v", err)
 		return
@@ -818,7 +508,7 @@ func TestSubnetIDsinVPC(t *testing.T) {
 	subnets[3]["az"] = "af-south-1c"
 	awsServices.ec2.Subnets = constructSubnets(subnets)
 
-	result, err = c.listSubnetIDsinVPC(vpcID)
+	result, err = c.listSubnetIDsinVPC(vpcID, nil)
 	if err != nil {
 		t.Errorf("Error listing subnets: %v", err)
 		return
@@ -829,6 +519,76 @@ func TestSubnetIDsinVPC(t *testing.T) {
 		return
 	}
 
+	// test that a public subnet is preferred within an AZ
+	awsServices.ec2.RouteTables = []*ec2.RouteTable{
+		{
+			Associations: []*ec2.RouteTableAssociation{{SubnetId: aws.String("subnet-c0000002")}},
+			Routes:       []*ec2.Route{{GatewayId: aws.String("igw-01234567")}},
+		},
+	}
+
+	result, err = c.listSubnetIDsinVPC(vpcID, nil)
+	if err != nil {
+		t.Errorf("Error listing subnets: %v", err)
+		return
+	}
+
+	result_set = make(map[string]bool)
+	for _, v := range result {
+		result_set[v] = true
+	}
+
+	if !result_set["subnet-c0000002"] {
+		t.Errorf("Expected public subnet 'subnet-c0000002' in result: %v", result)
+		return
+	}
+	if result_set["subnet-c0000001"] {
+		t.Errorf("Expected private subnet 'subnet-c0000001' to be skipped: %v", result)
+		return
+	}
+
+	// test that role-tagged subnets win over untagged ones
+	awsServices.ec2.RouteTables = nil
+	taggedSubnet := constructSubnet("subnet-c0000001", "af-south-1c")
+	taggedSubnet.Tags = []*ec2.Tag{{Key: aws.String(TagNameSubnetELB), Value: aws.String("")}}
+	awsServices.ec2.Subnets = append(constructSubnets(subnets), taggedSubnet)
+
+	result, err = c.listSubnetIDsinVPC(vpcID, nil)
+	if err != nil {
+		t.Errorf("Error listing subnets: %v", err)
+		return
+	}
+
+	if len(result) != 1 || result[0] != "subnet-c0000001" {
+		t.Errorf("Expected only tagged subnet 'subnet-c0000001' in result: %v", result)
+		return
+	}
+
+	// test that the availability zone restriction is applied
+	awsServices.ec2.Subnets = constructSubnets(subnets)
+
+	result, err = c.listSubnetIDsinVPC(vpcID, []string{"af-south-1b"})
+	if err != nil {
+		t.Errorf("Error listing subnets: %v", err)
+		return
+	}
+
+	if len(result) != 1 || result[0] != "subnet-b0000001" {
+		t.Errorf("Expected only subnet 'subnet-b0000001' in result: %v", result)
+		return
+	}
+
+	// test that we fall back to all subnets when no requested zone has one
+	result, err = c.listSubnetIDsinVPC(vpcID, []string{"af-south-1z"})
+	if err != nil {
+		t.Errorf("Error listing subnets: %v", err)
+		return
+	}
+
+	if len(result) != 3 {
+		t.Errorf("Expected all 3 subnets but got %d: %v", len(result), result)
+		return
+	}
 }
 
 func TestIpPermissionExistsHandlesMultipleGroupIds(t *testing.T) {
@@ -1050,3 +810,322 @@ func TestFindInstancesByNodeName(t *testing.T) {
 		t.Errorf("Expected node name %v but got %v", nodeNameOne, returnedInstances[0].PrivateDnsName)
 	}
 }
+
+func TestInstanceLifecycle(t *testing.T) {
+	var spotInstance ec2.Instance
+	spotInstance.InstanceId = aws.String("i-spot")
+	spotInstance.PrivateDnsName = aws.String("instance-spot.ec2.internal")
+	spotInstance.InstanceLifecycle = aws.String(ec2.InstanceLifecycleTypeSpot)
+	spotInstance.Placement = &ec2.Placement{
+		GroupName: aws.String("cluster-group"),
+		Tenancy:   aws.String(ec2.TenancyDedicated),
+	}
+	spotState := ec2.InstanceState{
+		Name: aws.String("running"),
+	}
+	spotInstance.State = &spotState
+
+	var onDemandInstance ec2.Instance
+	onDemandInstance.InstanceId = aws.String("i-ondemand")
+	onDemandInstance.PrivateDnsName = aws.String("instance-ondemand.ec2.internal")
+	onDemandState := ec2.InstanceState{
+		Name: aws.String("running"),
+	}
+	onDemandInstance.State = &onDemandState
+
+	aws1, _ := mockInstancesResp([]*ec2.Instance{&spotInstance, &onDemandInstance})
+
+	info, err := aws1.InstanceLifecycle("instance-spot.ec2.internal")
+	if err != nil {
+		t.Errorf("Should not error when instance found: %v", err)
+		return
+	}
+	if info.Lifecycle != "spot" {
+		t.Errorf("Expected spot lifecycle but got %v", info.Lifecycle)
+	}
+	if info.PlacementGroup != "cluster-group" {
+		t.Errorf("Expected placement group cluster-group but got %v", info.PlacementGroup)
+	}
+	if info.Tenancy != "dedicated" {
+		t.Errorf("Expected dedicated tenancy but got %v", info.Tenancy)
+	}
+
+	info, err = aws1.InstanceLifecycle("instance-ondemand.ec2.internal")
+	if err != nil {
+		t.Errorf("Should not error when instance found: %v", err)
+		return
+	}
+	if info.Lifecycle != "on-demand" {
+		t.Errorf("Expected on-demand lifecycle but got %v", info.Lifecycle)
+	}
+
+	if _, err := aws1.InstanceLifecycle("instance-missing.ec2.internal"); err == nil {
+		t.Errorf("Should error when instance not found")
+	}
+}
+
+func TestCreateDiskValidatesVolumeOptions(t *testing.T) {
+	aws1, _ := mockInstancesResp([]*ec2.Instance{})
+
+	invalid := []VolumeOptions{
+		// IOPS only make sense for io1
+		{CapacityGB: 100, VolumeType: "gp2", IOPS: 1000},
+		{CapacityGB: 100, VolumeType: "standard", IOPS: 1000},
+		// io1 IOPS out of range
+		{CapacityGB: 100, VolumeType: "io1", IOPS: 50},
+		{CapacityGB: 4000, VolumeType: "io1", IOPS: 30000},
+		// more than 30 IOPS per GiB
+		{CapacityGB: 10, VolumeType: "io1", IOPS: 1000},
+		// not an EBS volume type
+		{CapacityGB: 100, VolumeType: "ssd"},
+	}
+	for _, options := range invalid {
+		if _, err := aws1.CreateDisk(&options); err == nil {
+			t.Errorf("Expected error for volume options %v", options)
+		}
+	}
+}
+
+func TestBuildListener(t *testing.T) {
+	const certificate = "arn:aws:iam::123456789012:server-certificate/test"
+
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		port             api.ServicePort
+		protocol         string
+		instanceProtocol string
+		certificate      string
+	}{
+		{
+			"no annotations gives a plain TCP listener",
+			map[string]string{},
+			api.ServicePort{Port: 80, NodePort: 8080, Protocol: api.ProtocolTCP},
+			"tcp", "tcp", "",
+		},
+		{
+			"certificate annotation terminates TLS on every port",
+			map[string]string{ServiceAnnotationLoadBalancerCertificate: certificate},
+			api.ServicePort{Port: 443, NodePort: 8443, Protocol: api.ProtocolTCP},
+			"ssl", "tcp", certificate,
+		},
+		{
+			"ssl-ports annotation restricts TLS by port number",
+			map[string]string{
+				ServiceAnnotationLoadBalancerCertificate: certificate,
+				ServiceAnnotationLoadBalancerSSLPorts:    "443",
+			},
+			api.ServicePort{Port: 80, NodePort: 8080, Protocol: api.ProtocolTCP},
+			"tcp", "tcp", "",
+		},
+		{
+			"ssl-ports annotation matches port names",
+			map[string]string{
+				ServiceAnnotationLoadBalancerCertificate: certificate,
+				ServiceAnnotationLoadBalancerSSLPorts:    "secure",
+			},
+			api.ServicePort{Name: "secure", Port: 443, NodePort: 8443, Protocol: api.ProtocolTCP},
+			"ssl", "tcp", certificate,
+		},
+		{
+			"http backends get an HTTPS listener",
+			map[string]string{
+				ServiceAnnotationLoadBalancerCertificate:     certificate,
+				ServiceAnnotationLoadBalancerBackendProtocol: "http",
+			},
+			api.ServicePort{Port: 443, NodePort: 8443, Protocol: api.ProtocolTCP},
+			"https", "http", certificate,
+		},
+	}
+	for _, test := range tests {
+		listener := buildListener(&test.port, test.annotations)
+		if orEmpty(listener.Protocol) != test.protocol {
+			t.Errorf("%s: expected protocol %q but got %q", test.name, test.protocol, orEmpty(listener.Protocol))
+		}
+		if orEmpty(listener.InstanceProtocol) != test.instanceProtocol {
+			t.Errorf("%s: expected instance protocol %q but got %q", test.name, test.instanceProtocol, orEmpty(listener.InstanceProtocol))
+		}
+		if orEmpty(listener.SSLCertificateId) != test.certificate {
+			t.Errorf("%s: expected certificate %q but got %q", test.name, test.certificate, orEmpty(listener.SSLCertificateId))
+		}
+		if orZero(listener.LoadBalancerPort) != int64(test.port.Port) || orZero(listener.InstancePort) != int64(test.port.NodePort) {
+			t.Errorf("%s: unexpected ports on listener %v", test.name, listener)
+		}
+	}
+}
+
+func TestGetEBSMountDevices(t *testing.T) {
+	big := &awsInstanceType{name: "m4.large"}
+	devices := big.getEBSMountDevices()
+	if len(devices) != 40 {
+		t.Errorf("Expected 40 mount devices for m4.large but got %d", len(devices))
+	}
+	if devices[0] != "f" || devices[10] != "p" {
+		t.Errorf("Unexpected single-letter devices: %v", devices[:11])
+	}
+	if devices[11] != "ba" {
+		t.Errorf("Expected two-letter devices after 'p' but got %q", devices[11])
+	}
+
+	small := &awsInstanceType{name: "t2.micro"}
+	if n := len(small.getEBSMountDevices()); n != 16 {
+		t.Errorf("Expected 16 mount devices for t2.micro but got %d", n)
+	}
+}
+
+func TestDisksAreAttached(t *testing.T) {
+	instance := &ec2.Instance{
+		InstanceId:     aws.String("i-running"),
+		PrivateDnsName: aws.String("my-dns.internal"),
+		InstanceType:   aws.String("m3.medium"),
+		Placement:      &ec2.Placement{AvailabilityZone: aws.String("us-east-1a")},
+		State:          &ec2.InstanceState{Code: aws.Int64(16), Name: aws.String("running")},
+		BlockDeviceMappings: []*ec2.InstanceBlockDeviceMapping{
+			{
+				DeviceName: aws.String("/dev/xvdf"),
+				Ebs:        &ec2.EbsInstanceBlockDevice{VolumeId: aws.String("vol-attached")},
+			},
+		},
+	}
+
+	c, _ := mockInstancesResp([]*ec2.Instance{instance})
+
+	attached, err := c.DisksAreAttached([]string{"vol-attached", "vol-detached"}, "my-dns.internal")
+	if err != nil {
+		t.Fatalf("Error checking attachments: %v", err)
+	}
+	if !attached["vol-attached"] {
+		t.Errorf("Expected vol-attached to be attached")
+	}
+	if attached["vol-detached"] {
+		t.Errorf("Expected vol-detached to not be attached")
+	}
+}
+
+func TestInstanceStatus(t *testing.T) {
+	var instance ec2.Instance
+	instance.InstanceId = aws.String("i-impaired")
+	instance.PrivateDnsName = aws.String("instance-impaired.ec2.internal")
+	instance.State = &ec2.InstanceState{Name: aws.String("running")}
+
+	aws1, awsServices := mockInstancesResp([]*ec2.Instance{&instance})
+	awsServices.ec2.InstanceStatuses = []*ec2.InstanceStatus{
+		{
+			InstanceId:     aws.String("i-impaired"),
+			SystemStatus:   &ec2.InstanceStatusSummary{Status: aws.String("impaired")},
+			InstanceStatus: &ec2.InstanceStatusSummary{Status: aws.String("ok")},
+			Events: []*ec2.InstanceStatusEvent{
+				{
+					Code:        aws.String("system-reboot"),
+					Description: aws.String("scheduled reboot"),
+				},
+			},
+		},
+	}
+
+	info, err := aws1.InstanceStatus("instance-impaired.ec2.internal")
+	if err != nil {
+		t.Fatalf("Should not error when instance found: %v", err)
+	}
+	if info.SystemStatus != "impaired" {
+		t.Errorf("Expected impaired system status but got %v", info.SystemStatus)
+	}
+	if info.InstanceStatus != "ok" {
+		t.Errorf("Expected ok instance status but got %v", info.InstanceStatus)
+	}
+	if len(info.Events) != 1 || info.Events[0].Code != "system-reboot" {
+		t.Errorf("Unexpected events: %v", info.Events)
+	}
+
+	if _, err := aws1.InstanceStatus("instance-missing.ec2.internal"); err == nil {
+		t.Errorf("Should error when instance not found")
+	}
+}
+
+func TestGetInstanceLabels(t *testing.T) {
+	var instance ec2.Instance
+	instance.InstanceId = aws.String("i-zoned")
+	instance.PrivateDnsName = aws.String("instance-zoned.ec2.internal")
+	instance.Placement = &ec2.Placement{AvailabilityZone: aws.String("us-west-2b")}
+	instance.State = &ec2.InstanceState{Name: aws.String("running")}
+
+	aws1, _ := mockInstancesResp([]*ec2.Instance{&instance})
+
+	labels, err := aws1.GetInstanceLabels("instance-zoned.ec2.internal")
+	if err != nil {
+		t.Fatalf("Should not error when instance found: %v", err)
+	}
+	if labels[unversioned.LabelZoneFailureDomain] != "us-west-2b" {
+		t.Errorf("Unexpected zone label: %v", labels)
+	}
+	if labels[unversioned.LabelZoneRegion] != "us-west-2" {
+		t.Errorf("Unexpected region label: %v", labels)
+	}
+}
+
+func TestCreateDiskValidatesAvailabilityZone(t *testing.T) {
+	aws1 := mockAvailabilityZone("us-east-1", "us-east-1a")
+	_, err := aws1.CreateDisk(&VolumeOptions{CapacityGB: 10, AvailabilityZone: "eu-west-1a"})
+	if err == nil {
+		t.Errorf("Should error when the zone is outside the provider's region")
+	}
+}
+
+func TestListClustersAndMaster(t *testing.T) {
+	var master ec2.Instance
+	master.InstanceId = aws.String("i-master")
+	master.PublicDnsName = aws.String("master.cluster-a.example.com")
+	master.State = &ec2.InstanceState{Name: aws.String("running")}
+	master.Tags = []*ec2.Tag{
+		{Key: aws.String(TagNameKubernetesCluster), Value: aws.String("cluster-a")},
+		{Key: aws.String(TagNameRoleMaster), Value: aws.String("")},
+	}
+
+	var node ec2.Instance
+	node.InstanceId = aws.String("i-node")
+	node.State = &ec2.InstanceState{Name: aws.String("running")}
+	node.Tags = []*ec2.Tag{
+		{Key: aws.String(TagNameKubernetesCluster), Value: aws.String("cluster-b")},
+	}
+
+	aws1, _ := mockInstancesResp([]*ec2.Instance{&master, &node})
+
+	clusters, err := aws1.ListClusters()
+	if err != nil {
+		t.Fatalf("Should not error listing clusters: %v", err)
+	}
+	if len(clusters) != 2 || clusters[0] != "cluster-a" || clusters[1] != "cluster-b" {
+		t.Errorf("Unexpected clusters: %v", clusters)
+	}
+
+	address, err := aws1.Master("cluster-a")
+	if err != nil {
+		t.Fatalf("Should not error finding master: %v", err)
+	}
+	if address != "master.cluster-a.example.com" {
+		t.Errorf("Unexpected master address: %s", address)
+	}
+}
+
+func TestWatchSpotTermination(t *testing.T) {
+	aws1, awsServices := mockInstancesResp(nil)
+
+	// With stop already closed and no notice pending, the watcher must
+	// return without invoking the handler
+	stop := make(chan struct{})
+	close(stop)
+
+	called := false
+	aws1.WatchSpotTermination(func(terminationTime time.Time) { called = true }, stop)
+	if called {
+		t.Errorf("Handler should not be called without a termination notice")
+	}
+
+	awsServices.spotTerminationTime = "2015-01-05T18:02:00Z"
+
+	var got time.Time
+	aws1.WatchSpotTermination(func(terminationTime time.Time) { got = terminationTime }, stop)
+	if got.UTC().Format(time.RFC3339) != "2015-01-05T18:02:00Z" {
+		t.Errorf("Unexpected termination time: %v", got)
+	}
+}
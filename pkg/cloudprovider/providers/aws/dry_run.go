@@ -0,0 +1,195 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+
+	"github.com/golang/glog"
+)
+
+// dryRunEC2 wraps an EC2 implementation so that mutating operations log
+// the request that would have been made and return a synthetic success
+// response; read operations pass through unchanged. It is installed when
+// the DryRun config option is set, so operators can preview what the
+// provider would do against a production account.
+type dryRunEC2 struct {
+	EC2
+}
+
+func (d *dryRunEC2) AttachVolume(request *ec2.AttachVolumeInput) (*ec2.VolumeAttachment, error) {
+	glog.Infof("dry-run: would call EC2 AttachVolume with %v", request)
+	return &ec2.VolumeAttachment{
+		Device:     request.Device,
+		InstanceId: request.InstanceId,
+		VolumeId:   request.VolumeId,
+		State:      aws.String("attached"),
+	}, nil
+}
+
+func (d *dryRunEC2) DetachVolume(request *ec2.DetachVolumeInput) (*ec2.VolumeAttachment, error) {
+	glog.Infof("dry-run: would call EC2 DetachVolume with %v", request)
+	return &ec2.VolumeAttachment{
+		InstanceId: request.InstanceId,
+		VolumeId:   request.VolumeId,
+		State:      aws.String("detached"),
+	}, nil
+}
+
+func (d *dryRunEC2) CreateVolume(request *ec2.CreateVolumeInput) (*ec2.Volume, error) {
+	glog.Infof("dry-run: would call EC2 CreateVolume with %v", request)
+	return &ec2.Volume{
+		AvailabilityZone: request.AvailabilityZone,
+		Size:             request.Size,
+		SnapshotId:       request.SnapshotId,
+		VolumeId:         aws.String("vol-dryrun"),
+	}, nil
+}
+
+func (d *dryRunEC2) DeleteVolume(request *ec2.DeleteVolumeInput) (*ec2.DeleteVolumeOutput, error) {
+	glog.Infof("dry-run: would call EC2 DeleteVolume with %v", request)
+	return &ec2.DeleteVolumeOutput{}, nil
+}
+
+func (d *dryRunEC2) CreateSnapshot(request *ec2.CreateSnapshotInput) (*ec2.Snapshot, error) {
+	glog.Infof("dry-run: would call EC2 CreateSnapshot with %v", request)
+	return &ec2.Snapshot{
+		SnapshotId: aws.String("snap-dryrun"),
+		VolumeId:   request.VolumeId,
+		State:      aws.String("completed"),
+	}, nil
+}
+
+func (d *dryRunEC2) CreateSecurityGroup(request *ec2.CreateSecurityGroupInput) (*ec2.CreateSecurityGroupOutput, error) {
+	glog.Infof("dry-run: would call EC2 CreateSecurityGroup with %v", request)
+	return &ec2.CreateSecurityGroupOutput{GroupId: aws.String("sg-dryrun")}, nil
+}
+
+func (d *dryRunEC2) DeleteSecurityGroup(request *ec2.DeleteSecurityGroupInput) (*ec2.DeleteSecurityGroupOutput, error) {
+	glog.Infof("dry-run: would call EC2 DeleteSecurityGroup with %v", request)
+	return &ec2.DeleteSecurityGroupOutput{}, nil
+}
+
+func (d *dryRunEC2) AuthorizeSecurityGroupIngress(request *ec2.AuthorizeSecurityGroupIngressInput) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	glog.Infof("dry-run: would call EC2 AuthorizeSecurityGroupIngress with %v", request)
+	return &ec2.AuthorizeSecurityGroupIngressOutput{}, nil
+}
+
+func (d *dryRunEC2) RevokeSecurityGroupIngress(request *ec2.RevokeSecurityGroupIngressInput) (*ec2.RevokeSecurityGroupIngressOutput, error) {
+	glog.Infof("dry-run: would call EC2 RevokeSecurityGroupIngress with %v", request)
+	return &ec2.RevokeSecurityGroupIngressOutput{}, nil
+}
+
+func (d *dryRunEC2) CreateTags(request *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+	glog.Infof("dry-run: would call EC2 CreateTags with %v", request)
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func (d *dryRunEC2) CreateRoute(request *ec2.CreateRouteInput) (*ec2.CreateRouteOutput, error) {
+	glog.Infof("dry-run: would call EC2 CreateRoute with %v", request)
+	return &ec2.CreateRouteOutput{}, nil
+}
+
+func (d *dryRunEC2) DeleteRoute(request *ec2.DeleteRouteInput) (*ec2.DeleteRouteOutput, error) {
+	glog.Infof("dry-run: would call EC2 DeleteRoute with %v", request)
+	return &ec2.DeleteRouteOutput{}, nil
+}
+
+func (d *dryRunEC2) ModifyInstanceAttribute(request *ec2.ModifyInstanceAttributeInput) (*ec2.ModifyInstanceAttributeOutput, error) {
+	glog.Infof("dry-run: would call EC2 ModifyInstanceAttribute with %v", request)
+	return &ec2.ModifyInstanceAttributeOutput{}, nil
+}
+
+// dryRunELB is the ELB counterpart of dryRunEC2.
+type dryRunELB struct {
+	ELB
+}
+
+func (d *dryRunELB) CreateLoadBalancer(request *elb.CreateLoadBalancerInput) (*elb.CreateLoadBalancerOutput, error) {
+	glog.Infof("dry-run: would call ELB CreateLoadBalancer with %v", request)
+	return &elb.CreateLoadBalancerOutput{DNSName: aws.String("dryrun.elb.amazonaws.com")}, nil
+}
+
+func (d *dryRunELB) DeleteLoadBalancer(request *elb.DeleteLoadBalancerInput) (*elb.DeleteLoadBalancerOutput, error) {
+	glog.Infof("dry-run: would call ELB DeleteLoadBalancer with %v", request)
+	return &elb.DeleteLoadBalancerOutput{}, nil
+}
+
+func (d *dryRunELB) RegisterInstancesWithLoadBalancer(request *elb.RegisterInstancesWithLoadBalancerInput) (*elb.RegisterInstancesWithLoadBalancerOutput, error) {
+	glog.Infof("dry-run: would call ELB RegisterInstancesWithLoadBalancer with %v", request)
+	return &elb.RegisterInstancesWithLoadBalancerOutput{Instances: request.Instances}, nil
+}
+
+func (d *dryRunELB) DeregisterInstancesFromLoadBalancer(request *elb.DeregisterInstancesFromLoadBalancerInput) (*elb.DeregisterInstancesFromLoadBalancerOutput, error) {
+	glog.Infof("dry-run: would call ELB DeregisterInstancesFromLoadBalancer with %v", request)
+	return &elb.DeregisterInstancesFromLoadBalancerOutput{}, nil
+}
+
+func (d *dryRunELB) DetachLoadBalancerFromSubnets(request *elb.DetachLoadBalancerFromSubnetsInput) (*elb.DetachLoadBalancerFromSubnetsOutput, error) {
+	glog.Infof("dry-run: would call ELB DetachLoadBalancerFromSubnets with %v", request)
+	return &elb.DetachLoadBalancerFromSubnetsOutput{}, nil
+}
+
+func (d *dryRunELB) AttachLoadBalancerToSubnets(request *elb.AttachLoadBalancerToSubnetsInput) (*elb.AttachLoadBalancerToSubnetsOutput, error) {
+	glog.Infof("dry-run: would call ELB AttachLoadBalancerToSubnets with %v", request)
+	return &elb.AttachLoadBalancerToSubnetsOutput{Subnets: request.Subnets}, nil
+}
+
+func (d *dryRunELB) EnableAvailabilityZonesForLoadBalancer(request *elb.EnableAvailabilityZonesForLoadBalancerInput) (*elb.EnableAvailabilityZonesForLoadBalancerOutput, error) {
+	glog.Infof("dry-run: would call ELB EnableAvailabilityZonesForLoadBalancer with %v", request)
+	return &elb.EnableAvailabilityZonesForLoadBalancerOutput{AvailabilityZones: request.AvailabilityZones}, nil
+}
+
+func (d *dryRunELB) DisableAvailabilityZonesForLoadBalancer(request *elb.DisableAvailabilityZonesForLoadBalancerInput) (*elb.DisableAvailabilityZonesForLoadBalancerOutput, error) {
+	glog.Infof("dry-run: would call ELB DisableAvailabilityZonesForLoadBalancer with %v", request)
+	return &elb.DisableAvailabilityZonesForLoadBalancerOutput{}, nil
+}
+
+func (d *dryRunELB) CreateLoadBalancerListeners(request *elb.CreateLoadBalancerListenersInput) (*elb.CreateLoadBalancerListenersOutput, error) {
+	glog.Infof("dry-run: would call ELB CreateLoadBalancerListeners with %v", request)
+	return &elb.CreateLoadBalancerListenersOutput{}, nil
+}
+
+func (d *dryRunELB) DeleteLoadBalancerListeners(request *elb.DeleteLoadBalancerListenersInput) (*elb.DeleteLoadBalancerListenersOutput, error) {
+	glog.Infof("dry-run: would call ELB DeleteLoadBalancerListeners with %v", request)
+	return &elb.DeleteLoadBalancerListenersOutput{}, nil
+}
+
+func (d *dryRunELB) ApplySecurityGroupsToLoadBalancer(request *elb.ApplySecurityGroupsToLoadBalancerInput) (*elb.ApplySecurityGroupsToLoadBalancerOutput, error) {
+	glog.Infof("dry-run: would call ELB ApplySecurityGroupsToLoadBalancer with %v", request)
+	return &elb.ApplySecurityGroupsToLoadBalancerOutput{SecurityGroups: request.SecurityGroups}, nil
+}
+
+func (d *dryRunELB) ConfigureHealthCheck(request *elb.ConfigureHealthCheckInput) (*elb.ConfigureHealthCheckOutput, error) {
+	glog.Infof("dry-run: would call ELB ConfigureHealthCheck with %v", request)
+	return &elb.ConfigureHealthCheckOutput{HealthCheck: request.HealthCheck}, nil
+}
+
+func (d *dryRunELB) AddTags(request *elb.AddTagsInput) (*elb.AddTagsOutput, error) {
+	glog.Infof("dry-run: would call ELB AddTags with %v", request)
+	return &elb.AddTagsOutput{}, nil
+}
+
+func (d *dryRunELB) ModifyLoadBalancerAttributes(request *elb.ModifyLoadBalancerAttributesInput) (*elb.ModifyLoadBalancerAttributesOutput, error) {
+	glog.Infof("dry-run: would call ELB ModifyLoadBalancerAttributes with %v", request)
+	return &elb.ModifyLoadBalancerAttributesOutput{
+		LoadBalancerAttributes: request.LoadBalancerAttributes,
+		LoadBalancerName:       request.LoadBalancerName,
+	}, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aws
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const awsSubsystem = "cloudprovider_aws"
+
+var (
+	awsAPIRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: awsSubsystem,
+			Name:      "api_requests",
+			Help:      "Number of AWS API calls made, including retries. Broken down by service and operation",
+		},
+		[]string{"service", "operation"},
+	)
+
+	awsAPILatency = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Subsystem: awsSubsystem,
+			Name:      "api_request_latency_microseconds",
+			Help:      "Latency of AWS API calls in microseconds, measured over all retries of an operation. Broken down by service and operation",
+		},
+		[]string{"service", "operation"},
+	)
+
+	awsAPIErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: awsSubsystem,
+			Name:      "api_request_errors",
+			Help:      "Number of AWS API calls that returned an error. Broken down by service, operation and error code",
+		},
+		[]string{"service", "operation", "code"},
+	)
+
+	awsAPIThrottles = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: awsSubsystem,
+			Name:      "api_request_throttles",
+			Help:      "Number of AWS API calls that were throttled. Broken down by service and operation",
+		},
+		[]string{"service", "operation"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(awsAPIRequests)
+	prometheus.MustRegister(awsAPILatency)
+	prometheus.MustRegister(awsAPIErrors)
+	prometheus.MustRegister(awsAPIThrottles)
+}
+
+// requestLabels returns the service and operation of a request, for use as
+// metric labels
+func requestLabels(r *request.Request) (string, string) {
+	operation := "?"
+	if r.Operation != nil {
+		operation = r.Operation.Name
+	}
+	return r.ClientInfo.ServiceName, operation
+}
+
+// Handler for aws-sdk-go that counts each API call attempt; added to the
+// Sign chain so retries are counted too
+func awsMetricsRequestHandler(r *request.Request) {
+	service, operation := requestLabels(r)
+	awsAPIRequests.WithLabelValues(service, operation).Inc()
+}
+
+// Handler for aws-sdk-go that records errors, throttling, and the overall
+// latency of an operation; added to the back of the AfterRetry chain so it
+// observes the final outcome
+func awsMetricsAfterRetryHandler(r *request.Request) {
+	service, operation := requestLabels(r)
+
+	if r.Error != nil {
+		code := "internal"
+		if awsError, ok := r.Error.(awserr.Error); ok {
+			code = awsError.Code()
+		}
+		awsAPIErrors.WithLabelValues(service, operation, code).Inc()
+		if isErrorThrottle(r) {
+			awsAPIThrottles.WithLabelValues(service, operation).Inc()
+		}
+	}
+
+	if !r.WillRetry() {
+		awsAPILatency.WithLabelValues(service, operation).Observe(float64(time.Since(r.Time) / time.Microsecond))
+	}
+}
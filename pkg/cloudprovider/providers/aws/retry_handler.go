@@ -77,17 +77,35 @@ func (c *CrossRequestRetryDelay) AfterRetry(r *request.Request) {
 	if r.Error == nil {
 		return
 	}
-	awsError, ok := r.Error.(awserr.Error)
-	if !ok {
+	if !isErrorThrottle(r) {
 		return
 	}
-	if awsError.Code() == "RequestLimitExceeded" {
-		c.backoff.ReportError()
-		glog.Warningf("Got RequestLimitExceeded error on AWS request (%s)",
+	c.backoff.ReportError()
+	if r.WillRetry() {
+		glog.Warningf("Got throttling error on AWS request (%s); will retry (attempt %d)",
+			describeRequest(r), r.RetryCount+1)
+	} else {
+		glog.Warningf("Got throttling error on AWS request (%s); retries exhausted",
 			describeRequest(r))
 	}
 }
 
+// isErrorThrottle recognizes the errors the backoff should respond to: the
+// various throttling codes AWS returns, and server-side (5xx) errors, which
+// typically indicate the service itself is overloaded
+func isErrorThrottle(r *request.Request) bool {
+	if awsError, ok := r.Error.(awserr.Error); ok {
+		switch awsError.Code() {
+		case "RequestLimitExceeded", "Throttling", "ThrottlingException":
+			return true
+		}
+	}
+	if r.HTTPResponse != nil && r.HTTPResponse.StatusCode >= 500 {
+		return true
+	}
+	return false
+}
+
 // Backoff manages a backoff that varies based on the recently observed failures
 type Backoff struct {
 	decayIntervalSeconds int64
@@ -0,0 +1,179 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+eshealth prints a concise health report for the Elasticsearch instance
+behind cluster logging: overall red/yellow/green status, unassigned
+shard counts, and per-node disk usage with warnings when a node is close
+to the Elasticsearch disk watermarks. With --wait_for_green the tool
+polls until the cluster is green (or a timeout expires), which makes it
+usable as a gate in test scripts:
+
+    eshealth --wait_for_green --timeout=5m && run_logging_test.sh
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+var (
+	esURL         = flag.String("elasticsearch", "http://localhost:9200", "URL of the Elasticsearch server")
+	waitForGreen  = flag.Bool("wait_for_green", false, "Poll until the cluster status is green")
+	timeout       = flag.Duration("timeout", 10*time.Minute, "How long to wait with --wait_for_green before giving up")
+	pollInterval  = flag.Duration("poll_period", 10*time.Second, "Poll period used with --wait_for_green")
+	diskWarningAt = flag.Float64("disk_warning_percent", 85, "Warn when a node's disk usage exceeds this percentage (the default ES low watermark)")
+)
+
+// clusterHealth is the subset of the _cluster/health response we report.
+type clusterHealth struct {
+	ClusterName         string `json:"cluster_name"`
+	Status              string `json:"status"`
+	NumberOfNodes       int    `json:"number_of_nodes"`
+	NumberOfDataNodes   int    `json:"number_of_data_nodes"`
+	ActiveShards        int    `json:"active_shards"`
+	RelocatingShards    int    `json:"relocating_shards"`
+	InitializingShards  int    `json:"initializing_shards"`
+	UnassignedShards    int    `json:"unassigned_shards"`
+	ActivePrimaryShards int    `json:"active_primary_shards"`
+}
+
+// shardEntry is one row of _cat/shards.
+type shardEntry struct {
+	Index string `json:"index"`
+	Shard string `json:"shard"`
+	State string `json:"state"`
+}
+
+// nodeStats is the subset of _nodes/stats needed for disk reporting.
+type nodeStats struct {
+	Nodes map[string]struct {
+		Name string `json:"name"`
+		FS   struct {
+			Total struct {
+				TotalInBytes     int64 `json:"total_in_bytes"`
+				AvailableInBytes int64 `json:"available_in_bytes"`
+			} `json:"total"`
+		} `json:"fs"`
+	} `json:"nodes"`
+}
+
+func get(path string, out interface{}) error {
+	url := strings.TrimRight(*esURL, "/") + path
+	resp, err := http.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to query %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response from %s: %v", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("query to %s failed with status %d: %s", url, resp.StatusCode, raw)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response from %s: %v", url, err)
+	}
+	return nil
+}
+
+// report prints the health summary and returns the cluster status.
+func report() (string, error) {
+	var health clusterHealth
+	if err := get("/_cluster/health", &health); err != nil {
+		return "", err
+	}
+	fmt.Printf("cluster %s: %s\n", health.ClusterName, health.Status)
+	fmt.Printf("nodes=%d dataNodes=%d activeShards=%d (primaries=%d) relocating=%d initializing=%d unassigned=%d\n",
+		health.NumberOfNodes, health.NumberOfDataNodes, health.ActiveShards, health.ActivePrimaryShards,
+		health.RelocatingShards, health.InitializingShards, health.UnassignedShards)
+
+	// Break unassigned shards down by index so the offender is obvious.
+	if health.UnassignedShards > 0 {
+		var shards []shardEntry
+		if err := get("/_cat/shards?format=json", &shards); err != nil {
+			glog.Warningf("Failed to list shards: %v", err)
+		} else {
+			unassignedByIndex := map[string]int{}
+			for _, shard := range shards {
+				if shard.State == "UNASSIGNED" {
+					unassignedByIndex[shard.Index]++
+				}
+			}
+			for index, count := range unassignedByIndex {
+				fmt.Printf("unassigned: %d shards of %s\n", count, index)
+			}
+		}
+	}
+
+	// Per-node disk usage against the watermark.
+	var stats nodeStats
+	if err := get("/_nodes/stats/fs", &stats); err != nil {
+		glog.Warningf("Failed to get node stats: %v", err)
+	} else {
+		for _, node := range stats.Nodes {
+			total := node.FS.Total.TotalInBytes
+			if total == 0 {
+				continue
+			}
+			used := 100 * float64(total-node.FS.Total.AvailableInBytes) / float64(total)
+			warning := ""
+			if used >= *diskWarningAt {
+				warning = fmt.Sprintf("  WARNING: above %.0f%% disk watermark", *diskWarningAt)
+			}
+			fmt.Printf("node %s: disk %.1f%% used%s\n", node.Name, used, warning)
+		}
+	}
+	return health.Status, nil
+}
+
+func main() {
+	flag.Parse()
+
+	status, err := report()
+	if err != nil {
+		glog.Fatalf("Health check failed: %v", err)
+	}
+	if !*waitForGreen {
+		if status != "green" {
+			os.Exit(1)
+		}
+		return
+	}
+	for start := time.Now(); time.Since(start) < *timeout; time.Sleep(*pollInterval) {
+		if status == "green" {
+			return
+		}
+		glog.Infof("Cluster is %s, waiting for green", status)
+		status, err = report()
+		if err != nil {
+			glog.Warningf("Health check failed: %v", err)
+		}
+	}
+	glog.Errorf("Cluster did not reach green within %v", *timeout)
+	os.Exit(1)
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+esload bulk-indexes synthetic logstash-format documents directly into an
+Elasticsearch instance at a configurable rate and document size. It
+reports indexing throughput and rejection rates, which makes it possible
+to validate Elasticsearch sizing for cluster logging independently of
+fluentd and the rest of the collection pipeline.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+var (
+	esURL     = flag.String("elasticsearch", "http://localhost:9200", "URL of the Elasticsearch server to load")
+	index     = flag.String("index", "", "Index to write to; defaults to today's logstash-YYYY.MM.DD index")
+	docs      = flag.Int("docs", 100000, "Total number of documents to index")
+	docSize   = flag.Int("doc_size", 200, "Approximate size in bytes of the log field of each document")
+	batchSize = flag.Int("batch_size", 500, "Number of documents per bulk request")
+	workers   = flag.Int("workers", 4, "Number of concurrent bulk indexing workers")
+	rate      = flag.Int("rate", 0, "Target documents per second across all workers (0 means unthrottled)")
+)
+
+// bulkResponse is the subset of the _bulk API response needed to count
+// rejections.
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+	} `json:"items"`
+}
+
+// stats accumulates indexing outcomes across workers.
+type stats struct {
+	lock      sync.Mutex
+	indexed   int
+	rejected  int
+	failed    int
+	transport int
+}
+
+func (s *stats) add(indexed, rejected, failed int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.indexed += indexed
+	s.rejected += rejected
+	s.failed += failed
+}
+
+func (s *stats) addTransportError() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.transport++
+}
+
+// makeBatch builds the NDJSON body of one bulk request containing count
+// documents starting at sequence number seq.
+func makeBatch(index string, seq, count, size int) []byte {
+	var buf bytes.Buffer
+	action, _ := json.Marshal(map[string]interface{}{
+		"index": map[string]interface{}{"_index": index, "_type": "fluentd"},
+	})
+	padding := strings.Repeat("x", size)
+	for i := 0; i < count; i++ {
+		doc, _ := json.Marshal(map[string]interface{}{
+			"@timestamp": time.Now().Format(time.RFC3339Nano),
+			"log":        fmt.Sprintf("esload %d %s\n", seq+i, padding),
+			"stream":     "stdout",
+			"tag":        "esload",
+		})
+		buf.Write(action)
+		buf.WriteByte('\n')
+		buf.Write(doc)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// sendBatch issues one bulk request and records its outcome.
+func sendBatch(url string, body []byte, s *stats) {
+	resp, err := http.Post(url, "application/x-ndjson", bytes.NewReader(body))
+	if err != nil {
+		glog.Warningf("Bulk request failed: %v", err)
+		s.addTransportError()
+		return
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		glog.Warningf("Failed to read bulk response: %v", err)
+		s.addTransportError()
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		glog.Warningf("Bulk request failed with status %d: %s", resp.StatusCode, raw)
+		s.addTransportError()
+		return
+	}
+	var br bulkResponse
+	if err := json.Unmarshal(raw, &br); err != nil {
+		glog.Warningf("Failed to unmarshal bulk response: %v", err)
+		s.addTransportError()
+		return
+	}
+	indexed, rejected, failed := 0, 0, 0
+	for _, item := range br.Items {
+		for _, result := range item {
+			switch {
+			case result.Status >= 200 && result.Status < 300:
+				indexed++
+			case result.Status == http.StatusTooManyRequests:
+				// The indexing queue is full: the interesting signal
+				// when sizing an Elasticsearch deployment.
+				rejected++
+			default:
+				failed++
+			}
+		}
+	}
+	s.add(indexed, rejected, failed)
+}
+
+func main() {
+	flag.Parse()
+
+	target := *index
+	if target == "" {
+		target = time.Now().Format("logstash-2006.01.02")
+	}
+	url := fmt.Sprintf("%s/_bulk", strings.TrimRight(*esURL, "/"))
+	glog.Infof("Indexing %d documents of ~%d bytes into %s in batches of %d with %d workers",
+		*docs, *docSize, target, *batchSize, *workers)
+
+	// Each worker pulls batch start offsets from a channel so the total
+	// document count is exact regardless of worker count.
+	offsets := make(chan int)
+	go func() {
+		var throttle <-chan time.Time
+		if *rate > 0 {
+			interval := time.Duration(int64(time.Second) * int64(*batchSize) / int64(*rate))
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			throttle = ticker.C
+		}
+		for seq := 0; seq < *docs; seq += *batchSize {
+			if throttle != nil {
+				<-throttle
+			}
+			offsets <- seq
+		}
+		close(offsets)
+	}()
+
+	s := &stats{}
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < *workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seq := range offsets {
+				count := *batchSize
+				if seq+count > *docs {
+					count = *docs - seq
+				}
+				sendBatch(url, makeBatch(target, seq, count, *docSize), s)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	fmt.Printf("indexed=%d rejected=%d failed=%d transportErrors=%d\n",
+		s.indexed, s.rejected, s.failed, s.transport)
+	fmt.Printf("elapsed=%v throughput=%.1f docs/sec rejectionRate=%.2f%%\n",
+		elapsed,
+		float64(s.indexed)/elapsed.Seconds(),
+		100*float64(s.rejected)/float64(*docs))
+}
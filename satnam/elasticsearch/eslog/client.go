@@ -0,0 +1,203 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// failoverPasses is how many times Search cycles through all
+	// endpoints before giving up.
+	failoverPasses = 3
+	// failoverBackoff is the initial delay between passes; it doubles
+	// after each unsuccessful pass.
+	failoverBackoff = 500 * time.Millisecond
+)
+
+// Client talks to an Elasticsearch cluster. It holds one or more
+// endpoint URLs; requests that fail against one endpoint are retried
+// against the others, since during scale tests individual Elasticsearch
+// pods are regularly rescheduled mid-query. A Client is safe for
+// concurrent use.
+type Client struct {
+	endpoints  []string
+	mu         sync.Mutex // guards next
+	next       int
+	httpClient *http.Client
+}
+
+// NewClient returns a client for the Elasticsearch endpoints given as a
+// comma separated list of URLs, e.g.
+// http://elasticsearch-logging:9200. Every endpoint is assumed to serve
+// the same cluster.
+func NewClient(urls string) *Client {
+	endpoints := []string{}
+	for _, url := range strings.Split(urls, ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			endpoints = append(endpoints, strings.TrimRight(url, "/"))
+		}
+	}
+	return &Client{
+		endpoints:  endpoints,
+		httpClient: http.DefaultClient,
+	}
+}
+
+// NewClientWithTransport returns a client like NewClient whose requests
+// go through rt. This lets callers reach Elasticsearch through an
+// authenticated path such as the API server service proxy.
+func NewClientWithTransport(urls string, rt http.RoundTripper) *Client {
+	c := NewClient(urls)
+	c.httpClient = &http.Client{Transport: rt}
+	return c
+}
+
+// RequestError describes a failed request. Status is the HTTP status
+// code, or zero if the request never produced a response.
+type RequestError struct {
+	Status int
+	Err    error
+}
+
+func (e *RequestError) Error() string { return e.Err.Error() }
+
+// IsFatal returns true if err is a request error that retrying cannot
+// fix, such as a malformed query being rejected with a 4xx status.
+// Transport errors, 5xx responses and 429 (queue full) responses are
+// considered transient.
+func IsFatal(err error) bool {
+	re, ok := err.(*RequestError)
+	if !ok {
+		return false
+	}
+	return re.Status >= 400 && re.Status < 500 && re.Status != http.StatusTooManyRequests
+}
+
+// do issues a request, rotating through the configured endpoints and
+// backing off between passes until one succeeds, a fatal error is
+// returned, or the passes are exhausted.
+func (c *Client) do(method, path string, data []byte) ([]byte, error) {
+	if len(c.endpoints) == 0 {
+		return nil, fmt.Errorf("no Elasticsearch endpoints configured")
+	}
+	var lastErr error
+	backoff := failoverBackoff
+	for pass := 0; pass < failoverPasses; pass++ {
+		for range c.endpoints {
+			c.mu.Lock()
+			endpoint := c.endpoints[c.next%len(c.endpoints)]
+			c.mu.Unlock()
+			url := endpoint + path
+			raw, err := c.doOnce(method, url, data)
+			if err == nil {
+				return raw, nil
+			}
+			if IsFatal(err) {
+				// Retrying a rejected request against another node
+				// will only produce the same rejection.
+				return nil, err
+			}
+			lastErr = err
+			glog.Warningf("Request to %s failed, trying next endpoint: %v", url, err)
+			c.mu.Lock()
+			c.next++
+			c.mu.Unlock()
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return nil, fmt.Errorf("all %d Elasticsearch endpoints failed, last error: %v", len(c.endpoints), lastErr)
+}
+
+func (c *Client) doOnce(method, url string, data []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, &RequestError{Err: fmt.Errorf("failed to query %s: %v", url, err)}
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &RequestError{Err: fmt.Errorf("failed to read response from %s: %v", url, err)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &RequestError{
+			Status: resp.StatusCode,
+			Err:    fmt.Errorf("query to %s failed with status %d: %s", url, resp.StatusCode, raw),
+		}
+	}
+	return raw, nil
+}
+
+// Search POSTs body to the _search endpoint of the given indices (a
+// comma separated list or pattern such as "logstash-*") and returns the
+// decoded response.
+func (c *Client) Search(indices string, body map[string]interface{}) (*Result, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal query: %v", err)
+	}
+	glog.V(2).Infof("Query: %s", data)
+	raw, err := c.do("POST", fmt.Sprintf("/%s/_search", indices), data)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+	return result, nil
+}
+
+// SearchAll pages through every hit matching q, fetching pageSize
+// documents per request, and returns them all. The query's own Size
+// field is ignored.
+func (c *Client) SearchAll(indices string, q Query, pageSize int) ([]Hit, error) {
+	if pageSize <= 0 {
+		pageSize = 1000
+	}
+	all := []Hit{}
+	for from := 0; ; from += pageSize {
+		body := q.Body()
+		body["from"] = from
+		body["size"] = pageSize
+		result, err := c.Search(indices, body)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, result.Hits.Hits...)
+		if len(all) >= result.Hits.Total || len(result.Hits.Hits) == 0 {
+			return all, nil
+		}
+	}
+}
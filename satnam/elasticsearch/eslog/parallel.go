@@ -0,0 +1,97 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eslog
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// DailyIndices returns the daily logstash-style index names (prefix
+// followed by YYYY.MM.DD) covering the UTC days from newer through older
+// inclusive. Querying the daily indices individually lets a multi-day
+// range be fetched in parallel instead of as one serial scan of
+// logstash-*.
+func DailyIndices(prefix string, newer, older time.Time) []string {
+	newer, older = newer.UTC(), older.UTC()
+	indices := []string{}
+	day := time.Date(newer.Year(), newer.Month(), newer.Day(), 0, 0, 0, 0, time.UTC)
+	for !day.After(older) {
+		indices = append(indices, prefix+day.Format("2006.01.02"))
+		day = day.AddDate(0, 0, 1)
+	}
+	return indices
+}
+
+// byTimestamp sorts hits by their @timestamp field. The timestamps are
+// fixed-width RFC3339 strings in a single timezone, so the string order
+// is the chronological order.
+type byTimestamp []Hit
+
+func (h byTimestamp) Len() int           { return len(h) }
+func (h byTimestamp) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h byTimestamp) Less(i, j int) bool { return h[i].Source.Timestamp < h[j].Source.Timestamp }
+
+// SearchParallel runs q against each index separately with at most
+// workers requests in flight and returns the hits of all indices merged
+// in chronological order. Like SearchAll it returns partial results
+// together with the last error if some index queries fail, so callers
+// can still use what arrived. Indices that do not exist (days with no
+// ingested logs) are skipped without being treated as failures.
+func (c *Client) SearchParallel(indices []string, q Query, workers int) ([]Hit, error) {
+	if workers <= 0 {
+		workers = 4
+	}
+	type indexResult struct {
+		hits []Hit
+		err  error
+	}
+	results := make([]indexResult, len(indices))
+	limit := make(chan bool, workers)
+	var wg sync.WaitGroup
+	for i := range indices {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			limit <- true
+			defer func() { <-limit }()
+			hits, err := c.SearchAll(indices[i], q, 1000)
+			if re, ok := err.(*RequestError); ok && re.Status == 404 {
+				// The index for that day was never created.
+				glog.V(2).Infof("Index %s does not exist, skipping", indices[i])
+				err = nil
+			}
+			results[i] = indexResult{hits: hits, err: err}
+		}(i)
+	}
+	wg.Wait()
+
+	all := []Hit{}
+	var lastErr error
+	for i, result := range results {
+		if result.err != nil {
+			glog.Warningf("Search of index %s failed: %v", indices[i], result.err)
+			lastErr = result.err
+		}
+		all = append(all, result.hits...)
+	}
+	sort.Stable(byTimestamp(all))
+	return all, lastErr
+}
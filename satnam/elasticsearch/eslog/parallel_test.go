@@ -0,0 +1,66 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eslog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDailyIndices(t *testing.T) {
+	tests := []struct {
+		name     string
+		newer    time.Time
+		older    time.Time
+		expected []string
+	}{
+		{
+			name:     "single day",
+			newer:    time.Date(2015, 1, 5, 9, 0, 0, 0, time.UTC),
+			older:    time.Date(2015, 1, 5, 18, 0, 0, 0, time.UTC),
+			expected: []string{"logstash-2015.01.05"},
+		},
+		{
+			name:  "range crossing a month boundary",
+			newer: time.Date(2015, 1, 30, 23, 0, 0, 0, time.UTC),
+			older: time.Date(2015, 2, 2, 1, 0, 0, 0, time.UTC),
+			expected: []string{
+				"logstash-2015.01.30", "logstash-2015.01.31",
+				"logstash-2015.02.01", "logstash-2015.02.02",
+			},
+		},
+		{
+			name:     "non-UTC times are mapped to UTC days",
+			newer:    time.Date(2015, 1, 5, 23, 0, 0, 0, time.FixedZone("UTC-4", -4*60*60)),
+			older:    time.Date(2015, 1, 6, 5, 0, 0, 0, time.FixedZone("UTC-4", -4*60*60)),
+			expected: []string{"logstash-2015.01.06"},
+		},
+		{
+			name:     "inverted range",
+			newer:    time.Date(2015, 1, 6, 0, 0, 0, 0, time.UTC),
+			older:    time.Date(2015, 1, 5, 0, 0, 0, 0, time.UTC),
+			expected: []string{},
+		},
+	}
+	for _, test := range tests {
+		indices := DailyIndices("logstash-", test.newer, test.older)
+		if !reflect.DeepEqual(indices, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, indices)
+		}
+	}
+}
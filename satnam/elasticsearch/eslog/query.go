@@ -0,0 +1,134 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eslog
+
+import (
+	"strings"
+	"time"
+)
+
+// regexpMetacharacters are the characters with special meaning in
+// Elasticsearch's regexp query syntax, including all of the optional
+// operators, which are enabled by default.
+const regexpMetacharacters = `.?+*|{}[]()"\#@&<>~`
+
+// EscapeRegexp escapes s so that a regexp query matches it literally.
+func EscapeRegexp(s string) string {
+	escaped := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(regexpMetacharacters, s[i]) >= 0 {
+			escaped = append(escaped, '\\')
+		}
+		escaped = append(escaped, s[i])
+	}
+	return string(escaped)
+}
+
+// Query describes a search over the logging indices. The zero value
+// matches all documents. Clauses set on the struct are combined with a
+// bool/must so they all apply.
+type Query struct {
+	// Field and Term request an exact term match, e.g. Field
+	// "kubernetes_pod_name" and Term a pod name.
+	Field string
+	Term  string
+	// Text is a free-text query matched against the log field.
+	Text string
+	// Grep restricts results to log lines matching this regular
+	// expression, evaluated server-side with Elasticsearch's regexp
+	// query against the log field. Use EscapeRegexp to match a string
+	// literally. Unlike Text, which is analyzed and matches whole
+	// terms, Grep matches against the raw line.
+	Grep string
+	// Node restricts results to logs collected on the named node: every
+	// pod that ran there plus the node's host-level logs.
+	Node string
+	// Newer and Older bound the @timestamp of returned documents.
+	// Zero times mean no bound.
+	Newer time.Time
+	Older time.Time
+	// After restricts results to documents strictly newer than this
+	// @timestamp value. It is used by follow-style consumers to resume
+	// where the previous query left off.
+	After string
+	// Size is the maximum number of hits to return. Zero means the
+	// server default.
+	Size int
+}
+
+// Body assembles the Elasticsearch request body for the query. Callers
+// may further mutate the returned map, e.g. to attach aggregations.
+func (q Query) Body() map[string]interface{} {
+	must := []interface{}{}
+	if q.After != "" {
+		must = append(must, map[string]interface{}{
+			"range": map[string]interface{}{"@timestamp": map[string]interface{}{"gt": q.After}},
+		})
+	}
+	if q.Term != "" {
+		must = append(must, aliasedClause("term", q.Field, q.Term))
+	}
+	if q.Text != "" {
+		must = append(must, aliasedClause("match", "log", q.Text))
+	}
+	if q.Grep != "" {
+		must = append(must, aliasedClause("regexp", "log", q.Grep))
+	}
+	if q.Node != "" {
+		must = append(must, aliasedClause("term", "host", q.Node))
+	}
+	if !q.Newer.IsZero() || !q.Older.IsZero() {
+		timeRange := map[string]interface{}{}
+		if !q.Newer.IsZero() {
+			timeRange["gte"] = q.Newer.Format(time.RFC3339)
+		}
+		if !q.Older.IsZero() {
+			timeRange["lte"] = q.Older.Format(time.RFC3339)
+		}
+		must = append(must, map[string]interface{}{
+			"range": map[string]interface{}{"@timestamp": timeRange},
+		})
+	}
+	if len(must) == 0 {
+		must = append(must, map[string]interface{}{"match_all": map[string]interface{}{}})
+	}
+	body := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"must": must},
+		},
+		"sort": []interface{}{
+			map[string]interface{}{"@timestamp": map[string]interface{}{"order": "asc"}},
+		},
+	}
+	if q.Size > 0 {
+		body["size"] = q.Size
+	}
+	return body
+}
+
+// AddTermsAggregation attaches a terms aggregation over field to a query
+// body produced by Body. The aggregation is named after the field.
+func AddTermsAggregation(body map[string]interface{}, field string, size int) {
+	aggs, ok := body["aggs"].(map[string]interface{})
+	if !ok {
+		aggs = map[string]interface{}{}
+		body["aggs"] = aggs
+	}
+	aggs[field] = map[string]interface{}{
+		"terms": map[string]interface{}{"field": field, "size": size},
+	}
+}
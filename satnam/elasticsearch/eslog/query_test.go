@@ -0,0 +1,138 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eslog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEscapeRegexp(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"plain-text_123", "plain-text_123"},
+		{"a.b", `a\.b`},
+		{"GET /healthz.*50[0-9]", `GET /healthz\.\*50\[0-9\]`},
+		{`back\slash`, `back\\slash`},
+		{`"quoted" <tag> {x}`, `\"quoted\" \<tag\> \{x\}`},
+	}
+	for _, test := range tests {
+		if escaped := EscapeRegexp(test.input); escaped != test.expected {
+			t.Errorf("EscapeRegexp(%q) = %q, expected %q", test.input, escaped, test.expected)
+		}
+	}
+}
+
+// mustClauses digs the bool/must clause list out of a query body.
+func mustClauses(t *testing.T, body map[string]interface{}) []interface{} {
+	query, ok := body["query"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("body has no query: %v", body)
+	}
+	boolQuery, ok := query["bool"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("query is not a bool query: %v", query)
+	}
+	must, ok := boolQuery["must"].([]interface{})
+	if !ok {
+		t.Fatalf("bool query has no must list: %v", boolQuery)
+	}
+	return must
+}
+
+func TestQueryBodyZeroValue(t *testing.T) {
+	body := Query{}.Body()
+
+	must := mustClauses(t, body)
+	expected := []interface{}{
+		map[string]interface{}{"match_all": map[string]interface{}{}},
+	}
+	if !reflect.DeepEqual(must, expected) {
+		t.Errorf("expected a single match_all clause, got %v", must)
+	}
+	if _, found := body["size"]; found {
+		t.Errorf("zero query should not set size, got %v", body["size"])
+	}
+
+	expectedSort := []interface{}{
+		map[string]interface{}{"@timestamp": map[string]interface{}{"order": "asc"}},
+	}
+	if !reflect.DeepEqual(body["sort"], expectedSort) {
+		t.Errorf("expected ascending @timestamp sort, got %v", body["sort"])
+	}
+}
+
+func TestQueryBodyClauses(t *testing.T) {
+	newer := time.Date(2015, 1, 5, 12, 0, 0, 0, time.UTC)
+	older := time.Date(2015, 1, 5, 18, 0, 0, 0, time.UTC)
+	q := Query{
+		Field: "stream",
+		Term:  "stderr",
+		Text:  "connection refused",
+		Node:  "minion-1",
+		Newer: newer,
+		Older: older,
+		After: "2015-01-05T13:00:00Z",
+		Size:  42,
+	}
+	body := q.Body()
+
+	must := mustClauses(t, body)
+	expected := []interface{}{
+		map[string]interface{}{
+			"range": map[string]interface{}{"@timestamp": map[string]interface{}{"gt": "2015-01-05T13:00:00Z"}},
+		},
+		// The stream field has no aliases, so the term clause is direct.
+		map[string]interface{}{"term": map[string]interface{}{"stream": "stderr"}},
+		aliasedClause("match", "log", "connection refused"),
+		aliasedClause("term", "host", "minion-1"),
+		map[string]interface{}{
+			"range": map[string]interface{}{"@timestamp": map[string]interface{}{
+				"gte": newer.Format(time.RFC3339),
+				"lte": older.Format(time.RFC3339),
+			}},
+		},
+	}
+	if !reflect.DeepEqual(must, expected) {
+		t.Errorf("expected must clauses %v, got %v", expected, must)
+	}
+	if body["size"] != 42 {
+		t.Errorf("expected size 42, got %v", body["size"])
+	}
+}
+
+func TestAddTermsAggregation(t *testing.T) {
+	body := Query{}.Body()
+	AddTermsAggregation(body, "kubernetes_pod_name", 10)
+	AddTermsAggregation(body, "host", 5)
+
+	expected := map[string]interface{}{
+		"kubernetes_pod_name": map[string]interface{}{
+			"terms": map[string]interface{}{"field": "kubernetes_pod_name", "size": 10},
+		},
+		"host": map[string]interface{}{
+			"terms": map[string]interface{}{"field": "host", "size": 5},
+		},
+	}
+	if !reflect.DeepEqual(body["aggs"], expected) {
+		t.Errorf("expected aggregations %v, got %v", expected, body["aggs"])
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eslog
+
+import "encoding/json"
+
+// The collectors that feed cluster logging do not agree on field names:
+// the fluentd-elasticsearch addon writes flat documents with "log" and
+// "tag", while logstash-style collectors write "message" and nest pod
+// metadata under "kubernetes". This file maps both shapes onto the
+// canonical LogEntry fields so that callers can filter and read logs
+// without knowing which collector produced them.
+
+// fieldAliases maps each canonical field name to the concrete document
+// fields that may carry it, in order of preference.
+var fieldAliases = map[string][]string{
+	"log":                 {"log", "message"},
+	"kubernetes_pod_name": {"kubernetes_pod_name", "kubernetes.pod_name"},
+	"host":                {"host", "hostname", "kubernetes.host"},
+	"tag":                 {"tag", "kubernetes.container_name"},
+	"@timestamp":          {"@timestamp", "time"},
+}
+
+// aliasedClause builds a query clause (of kind "term" or "match") for a
+// canonical field. If the field has aliases the clause becomes a
+// bool/should over all of them so documents from any collector match.
+func aliasedClause(kind, field, value string) map[string]interface{} {
+	aliases, ok := fieldAliases[field]
+	if !ok {
+		return map[string]interface{}{
+			kind: map[string]interface{}{field: value},
+		}
+	}
+	should := []interface{}{}
+	for _, alias := range aliases {
+		should = append(should, map[string]interface{}{
+			kind: map[string]interface{}{alias: value},
+		})
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{"should": should},
+	}
+}
+
+// rawEntry is the superset of fields written by the supported
+// collectors.
+type rawEntry struct {
+	Timestamp  string `json:"@timestamp"`
+	Time       string `json:"time"`
+	Log        string `json:"log"`
+	Message    string `json:"message"`
+	Stream     string `json:"stream"`
+	Tag        string `json:"tag"`
+	Host       string `json:"host"`
+	Hostname   string `json:"hostname"`
+	PodName    string `json:"kubernetes_pod_name"`
+	Kubernetes struct {
+		PodName       string `json:"pod_name"`
+		ContainerName string `json:"container_name"`
+		Host          string `json:"host"`
+	} `json:"kubernetes"`
+}
+
+// UnmarshalJSON decodes a document from any supported collector into
+// the canonical fields.
+func (e *LogEntry) UnmarshalJSON(data []byte) error {
+	var raw rawEntry
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	e.Timestamp = first(raw.Timestamp, raw.Time)
+	e.Log = first(raw.Log, raw.Message)
+	e.Stream = raw.Stream
+	e.Tag = first(raw.Tag, raw.Kubernetes.ContainerName)
+	e.Host = first(raw.Host, raw.Hostname, raw.Kubernetes.Host)
+	e.PodName = first(raw.PodName, raw.Kubernetes.PodName)
+	return nil
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
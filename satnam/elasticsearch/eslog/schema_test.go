@@ -0,0 +1,114 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eslog
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAliasedClause(t *testing.T) {
+	// A field without aliases gets a direct clause.
+	clause := aliasedClause("term", "stream", "stderr")
+	expected := map[string]interface{}{
+		"term": map[string]interface{}{"stream": "stderr"},
+	}
+	if !reflect.DeepEqual(clause, expected) {
+		t.Errorf("expected direct clause %v, got %v", expected, clause)
+	}
+
+	// An aliased field becomes a bool/should over every alias so
+	// documents from any collector match.
+	clause = aliasedClause("match", "log", "error")
+	expected = map[string]interface{}{
+		"bool": map[string]interface{}{"should": []interface{}{
+			map[string]interface{}{"match": map[string]interface{}{"log": "error"}},
+			map[string]interface{}{"match": map[string]interface{}{"message": "error"}},
+		}},
+	}
+	if !reflect.DeepEqual(clause, expected) {
+		t.Errorf("expected aliased clause %v, got %v", expected, clause)
+	}
+}
+
+func TestFieldAliasesPreferCanonicalName(t *testing.T) {
+	for field, aliases := range fieldAliases {
+		if len(aliases) == 0 || aliases[0] != field {
+			t.Errorf("aliases for %s should list the canonical name first, got %v", field, aliases)
+		}
+	}
+}
+
+func TestLogEntryUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		expected LogEntry
+	}{
+		{
+			name: "fluentd-elasticsearch addon (flat fields)",
+			document: `{"@timestamp": "2015-01-05T18:02:00Z", "log": "hello\n", "stream": "stdout",
+				"tag": "synth-logger", "host": "minion-1", "kubernetes_pod_name": "synthlgr0"}`,
+			expected: LogEntry{
+				Timestamp: "2015-01-05T18:02:00Z",
+				Log:       "hello\n",
+				Stream:    "stdout",
+				Tag:       "synth-logger",
+				Host:      "minion-1",
+				PodName:   "synthlgr0",
+			},
+		},
+		{
+			name: "logstash-style collector (nested kubernetes metadata)",
+			document: `{"time": "2015-01-05T18:02:00Z", "message": "hello\n", "stream": "stdout",
+				"kubernetes": {"pod_name": "synthlgr0", "container_name": "synth-logger", "host": "minion-1"}}`,
+			expected: LogEntry{
+				Timestamp: "2015-01-05T18:02:00Z",
+				Log:       "hello\n",
+				Stream:    "stdout",
+				Tag:       "synth-logger",
+				Host:      "minion-1",
+				PodName:   "synthlgr0",
+			},
+		},
+		{
+			name: "canonical fields win over aliases",
+			document: `{"@timestamp": "2015-01-05T18:02:00Z", "time": "1999-01-01T00:00:00Z",
+				"log": "canonical\n", "message": "aliased\n"}`,
+			expected: LogEntry{
+				Timestamp: "2015-01-05T18:02:00Z",
+				Log:       "canonical\n",
+			},
+		},
+		{
+			name:     "empty document",
+			document: `{}`,
+			expected: LogEntry{},
+		},
+	}
+	for _, test := range tests {
+		entry := LogEntry{}
+		if err := json.Unmarshal([]byte(test.document), &entry); err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if entry != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.expected, entry)
+		}
+	}
+}
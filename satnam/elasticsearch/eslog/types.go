@@ -0,0 +1,62 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package eslog queries the Elasticsearch instance that backs cluster
+// logging. It provides client construction, query building, typed
+// decoding of the logstash documents produced by fluentd, and
+// pagination over large result sets. The getlogs command is a thin
+// wrapper around this package; e2e tests and other tools can use it to
+// inspect cluster logs programmatically.
+package eslog
+
+// LogEntry is the subset of a fluentd-generated logstash document that
+// callers typically care about. Unknown fields are ignored on decode.
+type LogEntry struct {
+	Timestamp string `json:"@timestamp"`
+	Log       string `json:"log"`
+	Stream    string `json:"stream"`
+	Tag       string `json:"tag"`
+	Host      string `json:"host,omitempty"`
+	PodName   string `json:"kubernetes_pod_name,omitempty"`
+}
+
+// Hit is a single search hit: the document plus its location.
+type Hit struct {
+	Index  string   `json:"_index"`
+	ID     string   `json:"_id"`
+	Source LogEntry `json:"_source"`
+}
+
+// Bucket is one entry of a terms aggregation.
+type Bucket struct {
+	Key      string `json:"key"`
+	DocCount int    `json:"doc_count"`
+}
+
+// Aggregation holds the buckets of one named aggregation.
+type Aggregation struct {
+	Buckets []Bucket `json:"buckets"`
+}
+
+// Result is the typed shape of an Elasticsearch search response.
+type Result struct {
+	TimedOut bool `json:"timed_out"`
+	Hits     struct {
+		Total int   `json:"total"`
+		Hits  []Hit `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]Aggregation `json:"aggregations"`
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"k8s.io/kubernetes/satnam/elasticsearch/eslog"
+)
+
+// exportLogs pages through the full result set for the flag-selected
+// query and uploads it to the destination object in compressed
+// JSON-lines form. Destinations have the form gs://bucket/object or
+// s3://bucket/object. This is used to archive logs from ephemeral test
+// clusters before they are torn down. If the search fails partway
+// through, whatever was retrieved is still uploaded and partial is
+// returned true so the caller can exit non-zero.
+func exportLogs(client *eslog.Client, destination, region string) (partial bool, err error) {
+	hits, err := client.SearchAll(*indices, flagQuery(""), 1000)
+	if err != nil {
+		if len(hits) == 0 {
+			return false, fmt.Errorf("search failed: %v", err)
+		}
+		glog.Warningf("Search failed after %d hits, exporting partial results: %v", len(hits), err)
+		partial = true
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(zw)
+	for _, hit := range hits {
+		if err := encoder.Encode(hit); err != nil {
+			return partial, fmt.Errorf("failed to encode hit: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return partial, fmt.Errorf("failed to compress results: %v", err)
+	}
+
+	u, uerr := url.Parse(destination)
+	if uerr != nil {
+		return partial, fmt.Errorf("bad destination %q: %v", destination, uerr)
+	}
+	object := strings.TrimPrefix(u.Path, "/")
+	switch u.Scheme {
+	case "gs":
+		err = uploadGCS(u.Host, object, buf.Bytes())
+	case "s3":
+		err = uploadS3(u.Host, object, region, buf.Bytes())
+	default:
+		err = fmt.Errorf("unsupported destination scheme %q (want gs or s3)", u.Scheme)
+	}
+	if err != nil {
+		return partial, err
+	}
+	fmt.Printf("exported %d log lines (%d compressed bytes) to %s\n", len(hits), buf.Len(), destination)
+	return partial, nil
+}
+
+// uploadGCS writes data to a GCS object using application default
+// credentials (gcloud login or a service account on GCE).
+func uploadGCS(bucket, object string, data []byte) error {
+	client, err := google.DefaultClient(oauth2.NoContext, "https://www.googleapis.com/auth/devstorage.read_write")
+	if err != nil {
+		return fmt.Errorf("failed to get GCS credentials: %v", err)
+	}
+	uploadURL := fmt.Sprintf("https://www.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		bucket, url.QueryEscape(object))
+	resp, err := client.Post(uploadURL, "application/gzip", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to upload to gs://%s/%s: %v", bucket, object, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upload to gs://%s/%s failed with status %d", bucket, object, resp.StatusCode)
+	}
+	return nil
+}
+
+// uploadS3 writes data to an S3 object. Credentials are taken from the
+// standard AWS environment variables; only a single PUT is needed so the
+// V4 signature is computed directly rather than pulling in the AWS SDK.
+func uploadS3(bucket, object, region string, data []byte) error {
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set for s3 export")
+	}
+
+	host := fmt.Sprintf("%s.s3-%s.amazonaws.com", bucket, region)
+	if region == "us-east-1" {
+		host = fmt.Sprintf("%s.s3.amazonaws.com", bucket)
+	}
+	req, err := http.NewRequest("PUT", fmt.Sprintf("https://%s/%s", host, object), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	payloadHash := hex.EncodeToString(hashSHA256(data))
+	req.Header.Set("Host", host)
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("X-Amz-Security-Token") != "" {
+		signed = append(signed, "x-amz-security-token")
+	}
+	canonicalHeaders := ""
+	for _, h := range signed {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = host
+		}
+		canonicalHeaders += h + ":" + value + "\n"
+	}
+	canonicalRequest := strings.Join([]string{
+		"PUT",
+		"/" + object,
+		"",
+		canonicalHeaders,
+		strings.Join(signed, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", now.Format("20060102"), region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format("20060102T150405Z"),
+		scope,
+		hex.EncodeToString(hashSHA256([]byte(canonicalRequest))),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, strings.Join(signed, ";"), signature))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload to s3://%s/%s: %v", bucket, object, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upload to s3://%s/%s failed with status %d", bucket, object, resp.StatusCode)
+	}
+	return nil
+}
+
+func hashSHA256(data []byte) []byte {
+	h := sha256.Sum256(data)
+	return h[:]
+}
+
+func hmacSHA256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
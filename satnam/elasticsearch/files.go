@@ -0,0 +1,152 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/kubernetes/satnam/elasticsearch/eslog"
+)
+
+var (
+	outputDir    = flag.String("output_dir", "", "Write raw log lines to files in this directory instead of stdout, so long --follow sessions can run unattended")
+	splitByPod   = flag.Bool("split_by_pod", false, "With --output_dir, write each pod's lines to its own file")
+	maxFileBytes = flag.Int64("max_file_bytes", 64*1024*1024, "With --output_dir, rotate a file once it reaches this size; rotated files keep a numeric suffix")
+)
+
+// fileEmitter writes raw log lines to files under a directory. During
+// multi-hour scale tests a follow session can retrieve gigabytes of
+// lines, which is unpleasant to stream through a terminal; writing to
+// files (optionally one per pod, and rotated by size) keeps the
+// retrieval running while the results are inspected separately.
+type fileEmitter struct {
+	dir      string
+	split    bool
+	maxBytes int64
+	files    map[string]*rotatingFile
+}
+
+// rotatingFile is one output file plus its rotation state.
+type rotatingFile struct {
+	base    string
+	file    *os.File
+	written int64
+	serial  int
+}
+
+func newFileEmitter(dir string, split bool, maxBytes int64) (*fileEmitter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory %s: %v", dir, err)
+	}
+	return &fileEmitter{dir: dir, split: split, maxBytes: maxBytes, files: map[string]*rotatingFile{}}, nil
+}
+
+// fileFor returns the file a hit belongs to, opening it on first use.
+// Pod names are valid path components as-is; hits without a pod name
+// (host-level logs) go to a catch-all file.
+func (e *fileEmitter) fileFor(hit eslog.Hit) (*rotatingFile, error) {
+	name := "logs"
+	if e.split {
+		if pod := strings.TrimSpace(hit.Source.PodName); pod != "" {
+			name = pod
+		} else {
+			name = "host"
+		}
+	}
+	rf := e.files[name]
+	if rf == nil {
+		rf = &rotatingFile{base: filepath.Join(e.dir, name+".log")}
+		if err := rf.open(); err != nil {
+			return nil, err
+		}
+		e.files[name] = rf
+	}
+	return rf, nil
+}
+
+func (e *fileEmitter) emit(hit eslog.Hit) error {
+	rf, err := e.fileFor(hit)
+	if err != nil {
+		return err
+	}
+	if rf.written >= e.maxBytes {
+		if err := rf.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := rf.file.WriteString(hit.Source.Log)
+	rf.written += int64(n)
+	return err
+}
+
+// close flushes to disk but keeps the emitter usable: follow mode calls
+// close after every poll round, and emit reopens files on demand.
+func (e *fileEmitter) close() error {
+	var firstErr error
+	for _, rf := range e.files {
+		if err := rf.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	e.files = map[string]*rotatingFile{}
+	return firstErr
+}
+
+func (rf *rotatingFile) open() error {
+	file, err := os.OpenFile(rf.base, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", rf.base, err)
+	}
+	rf.file = file
+	// Pick up where a previous session (or poll round) left off.
+	if info, err := file.Stat(); err == nil {
+		rf.written = info.Size()
+	}
+	return nil
+}
+
+// rotate renames the current file to base.N and starts a fresh one. The
+// serial only grows, so earlier rotations are never overwritten.
+func (rf *rotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	for {
+		rf.serial++
+		rotated := fmt.Sprintf("%s.%d", rf.base, rf.serial)
+		if _, err := os.Stat(rotated); os.IsNotExist(err) {
+			if err := os.Rename(rf.base, rotated); err != nil {
+				return fmt.Errorf("failed to rotate %s: %v", rf.base, err)
+			}
+			break
+		}
+	}
+	rf.written = 0
+	return rf.open()
+}
+
+// chooseEmitter returns the emitter selected by the output flags.
+func chooseEmitter() (emitter, error) {
+	if *outputDir != "" {
+		return newFileEmitter(*outputDir, *splitByPod, *maxFileBytes)
+	}
+	return newEmitter(*output)
+}
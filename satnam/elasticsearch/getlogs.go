@@ -0,0 +1,481 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+getlogs queries the cluster-level Elasticsearch logging instance for log
+lines ingested by fluentd. A query is assembled from flags: an exact term
+match on a chosen field, a free-text query string, and a time range. For
+example, to fetch the last hour of lines from a synthetic logger pod:
+
+    getlogs --term=synthlgr0_25 --since=1h
+
+or to free-text search across all logstash indices:
+
+    getlogs --query="connection refused" --size=100
+
+or to filter lines against a regular expression without downloading
+everything first — the expression is evaluated server-side:
+
+    getlogs --node=minion-1 --grep="GET /healthz.*50[0-9]" --since=1h
+
+The query machinery lives in the eslog package; this command is a thin
+wrapper that maps flags onto it and formats the results.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/satnam/elasticsearch/eslog"
+)
+
+var (
+	esURL   = flag.String("elasticsearch", "http://localhost:9200", "Comma separated list of Elasticsearch endpoint URLs to query; failed requests are retried against the other endpoints")
+	indices = flag.String("indices", "logstash-*", "Comma separated list of indices to search")
+	field   = flag.String("field", "kubernetes_pod_name", "Document field used for exact term matches")
+	term    = flag.String("term", "", "Exact value to match against --field, e.g. a pod name")
+	query   = flag.String("query", "", "Free-text query string matched against the log field")
+	grep    = flag.String("grep", "", "Only return lines whose log message matches this regular expression, evaluated server-side; combines with the other filters")
+	literal = flag.Bool("grep_literal", false, "Treat the --grep pattern as a literal string rather than a regular expression")
+	node    = flag.String("node", "", "Only return logs collected on this node: all of its pods plus its host-level logs")
+	since   = flag.Duration("since", 0, "Only return lines newer than this, e.g. 10m or 1h (0 means no lower bound)")
+	until   = flag.Duration("until", 0, "Only return lines older than this (0 means no upper bound)")
+	size    = flag.Int("size", 500, "Maximum number of log lines to return")
+	output  = flag.String("output", "raw", "Output format: raw (log lines only), json (one JSON document per line with full metadata) or csv")
+	follow  = flag.Bool("follow", false, "After printing the current results, poll for documents with newer @timestamp values and stream them as they are ingested")
+	poll    = flag.Duration("poll_period", 2*time.Second, "How often to poll for new documents in --follow mode")
+
+	parallel = flag.Int("parallel", 0, "When greater than zero, query each daily logstash index separately with this many concurrent requests and merge the results chronologically; requires --since and retrieves every matching line, ignoring --size")
+
+	export       = flag.String("export", "", "Write the full result set as compressed JSON lines to this gs://bucket/object or s3://bucket/object destination instead of printing it")
+	exportRegion = flag.String("export_region", "us-east-1", "AWS region of the s3 bucket used with --export")
+
+	aggregate = flag.Bool("aggregate", false, "Instead of printing log lines, print a table of log volume per pod, stream, tag and host over the selected time range")
+	topN      = flag.Int("top", 20, "Number of buckets to show for each aggregation in --aggregate mode")
+
+	alert          = flag.Bool("alert", false, "Periodically run the configured query and post a notification when new matches exceed the threshold")
+	alertPeriod    = flag.Duration("alert_period", time.Minute, "How often to run the query in --alert mode")
+	alertThreshold = flag.Int("alert_threshold", 1, "Minimum number of new matching lines in one period before a notification is posted")
+	alertWebhook   = flag.String("alert_webhook", "", "URL receiving a Slack-style JSON notification in --alert mode; when empty, alerts are only logged")
+
+	verify      = flag.Bool("verify", false, "Verify that the output of the synthetic loggers tainted with --taint arrived completely, then exit")
+	verifyPods  = flag.Int("verify_pods", 0, "Number of synthetic logger pods that ran")
+	verifyLines = flag.Int("verify_lines", 0, "Number of numbered lines each synthetic logger pod emitted")
+	taint       = flag.String("taint", "", "Taint string identifying the synthetic logger run to verify")
+)
+
+// flagQuery maps the query-related flags onto an eslog.Query. In follow
+// mode, after holds the @timestamp of the last document already seen.
+func flagQuery(after string) eslog.Query {
+	q := eslog.Query{
+		Field: *field,
+		Term:  *term,
+		Text:  *query,
+		Grep:  *grep,
+		Node:  *node,
+		After: after,
+		Size:  *size,
+	}
+	if *literal {
+		q.Grep = eslog.EscapeRegexp(*grep)
+	}
+	now := time.Now()
+	if *since != 0 {
+		q.Newer = now.Add(-*since)
+	}
+	if *until != 0 {
+		q.Older = now.Add(-*until)
+	}
+	return q
+}
+
+// aggregateLogs runs terms aggregations over the pod, stream, tag and
+// host fields within the selected time range and prints one table of log
+// volume per field. This makes noisy pods and fluentd gaps (nodes with
+// anomalously low volume) easy to spot.
+func aggregateLogs(client *eslog.Client) {
+	aggFields := []string{"kubernetes_pod_name", "stream", "tag", "host"}
+	body := flagQuery("").Body()
+	// We only want the aggregations, not the matching documents.
+	body["size"] = 0
+	delete(body, "sort")
+	for _, aggField := range aggFields {
+		eslog.AddTermsAggregation(body, aggField, *topN)
+	}
+
+	result, err := client.Search(*indices, body)
+	if err != nil {
+		glog.Fatalf("Aggregation search failed: %v", err)
+	}
+	fmt.Printf("total lines: %d\n", result.Hits.Total)
+	for _, aggField := range aggFields {
+		agg, ok := result.Aggregations[aggField]
+		if !ok {
+			glog.Warningf("Response is missing the %s aggregation", aggField)
+			continue
+		}
+		fmt.Printf("\nlines per %s:\n", aggField)
+		for _, b := range agg.Buckets {
+			fmt.Printf("%10d %s\n", b.DocCount, b.Key)
+		}
+	}
+}
+
+// verifyLogs checks that every numbered line written by a synthetic
+// logger run is present in Elasticsearch exactly once. The loggers (see
+// the es_cluster_logging e2e test) emit lines of the form
+// "<pod index> <taint> <line number> <pod name>". Missing and duplicated
+// lines are counted per pod and reported; the return value is true only
+// if the run is complete with no duplicates.
+func verifyLogs(client *eslog.Client) bool {
+	if *verifyPods <= 0 || *verifyLines <= 0 || *taint == "" {
+		glog.Fatalf("--verify requires --verify_pods, --verify_lines and --taint")
+	}
+	expected := *verifyPods * *verifyLines
+	partial := false
+	hits, err := client.SearchAll(*indices, eslog.Query{Text: *taint}, 1000)
+	if err != nil {
+		if len(hits) == 0 || eslog.IsFatal(err) {
+			glog.Fatalf("Verification search failed: %v", err)
+		}
+		// Partial results can still prove lines are missing, but a clean
+		// result would be meaningless, so fail verification either way.
+		glog.Warningf("Verification search returned only %d hits: %v", len(hits), err)
+		partial = true
+	}
+	glog.Infof("Expecting %d lines, search found %d", expected, len(hits))
+
+	observed := make([][]int, *verifyPods)
+	for i := range observed {
+		observed[i] = make([]int, *verifyLines)
+	}
+	malformed := 0
+	for _, hit := range hits {
+		words := strings.Fields(hit.Source.Log)
+		if len(words) != 4 || words[1] != *taint {
+			malformed++
+			continue
+		}
+		pod, err1 := strconv.Atoi(words[0])
+		line, err2 := strconv.Atoi(words[2])
+		if err1 != nil || err2 != nil || pod < 0 || pod >= *verifyPods || line < 0 || line >= *verifyLines {
+			malformed++
+			continue
+		}
+		observed[pod][line]++
+	}
+
+	missing, duplicates := 0, 0
+	for pod := range observed {
+		podMissing, podDuplicates := 0, 0
+		for line, count := range observed[pod] {
+			switch {
+			case count == 0:
+				podMissing++
+				glog.V(2).Infof("Pod %d line %d is missing", pod, line)
+			case count > 1:
+				podDuplicates += count - 1
+				glog.V(2).Infof("Pod %d line %d appears %d times", pod, line, count)
+			}
+		}
+		if podMissing > 0 || podDuplicates > 0 {
+			fmt.Printf("pod %d: missing=%d duplicates=%d\n", pod, podMissing, podDuplicates)
+		}
+		missing += podMissing
+		duplicates += podDuplicates
+	}
+	fmt.Printf("total: expected=%d missing=%d duplicates=%d malformed=%d\n",
+		expected, missing, duplicates, malformed)
+	return missing == 0 && duplicates == 0 && !partial
+}
+
+// parallelSearch retrieves the full result set for the selected time
+// range by querying each daily logstash index concurrently and emitting
+// the chronologically merged hits. For ranges spanning many days this is
+// much faster than one serial scan of logstash-*.
+func parallelSearch(client *eslog.Client) {
+	q := flagQuery("")
+	if q.Newer.IsZero() {
+		glog.Fatalf("--parallel requires --since")
+	}
+	older := q.Older
+	if older.IsZero() {
+		older = time.Now()
+	}
+	dailyIndices := eslog.DailyIndices("logstash-", q.Newer, older)
+	glog.Infof("Querying %d daily indices with %d workers", len(dailyIndices), *parallel)
+
+	emit, emitErr := chooseEmitter()
+	if emitErr != nil {
+		glog.Fatalf("Bad --output: %v", emitErr)
+	}
+	hits, err := client.SearchParallel(dailyIndices, q, *parallel)
+	if err != nil {
+		if len(hits) == 0 {
+			glog.Fatalf("Search failed: %v", err)
+		}
+		glog.Warningf("Results are incomplete: %v", err)
+	}
+	glog.Infof("Total hits: %d", len(hits))
+	for _, hit := range hits {
+		if err := emit.emit(hit); err != nil {
+			glog.Fatalf("Failed to write output: %v", err)
+		}
+	}
+	if err := emit.close(); err != nil {
+		glog.Fatalf("Failed to flush output: %v", err)
+	}
+}
+
+// alertPayload is the notification body posted to the webhook. The
+// Text field makes the payload directly consumable by Slack-style
+// incoming webhooks; the structured fields serve everything else.
+type alertPayload struct {
+	Text    string   `json:"text"`
+	Query   string   `json:"query"`
+	Count   int      `json:"count"`
+	Period  string   `json:"period"`
+	Samples []string `json:"samples,omitempty"`
+}
+
+func postAlert(url string, payload alertPayload) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// alertLogs periodically runs the configured query and posts a
+// notification whenever one period produces at least the threshold of
+// new matching lines, turning the logging stack into a basic alerting
+// source. The first period is seeded with the newest already-ingested
+// match so only lines ingested after startup can alert.
+func alertLogs(client *eslog.Client) {
+	description := *query
+	if *grep != "" {
+		description = *grep
+	}
+
+	lastSeen := ""
+	if result, err := client.Search(*indices, flagQuery("").Body()); err != nil {
+		glog.Warningf("Seed search failed; the first period may include historical matches: %v", err)
+	} else if n := len(result.Hits.Hits); n > 0 {
+		lastSeen = result.Hits.Hits[n-1].Source.Timestamp
+	}
+
+	for {
+		time.Sleep(*alertPeriod)
+		result, err := client.Search(*indices, flagQuery(lastSeen).Body())
+		if err != nil {
+			glog.Warningf("Alert search failed: %v", err)
+			continue
+		}
+		hits := result.Hits.Hits
+		if len(hits) > 0 {
+			lastSeen = hits[len(hits)-1].Source.Timestamp
+		}
+		if len(hits) < *alertThreshold {
+			glog.V(2).Infof("%d new matches for %q (threshold %d)", len(hits), description, *alertThreshold)
+			continue
+		}
+
+		samples := []string{}
+		for _, hit := range hits {
+			if len(samples) == 5 {
+				break
+			}
+			samples = append(samples, strings.TrimSpace(hit.Source.Log))
+		}
+		payload := alertPayload{
+			Text:    fmt.Sprintf("%d log lines matched %q in the last %v", len(hits), description, *alertPeriod),
+			Query:   description,
+			Count:   len(hits),
+			Period:  alertPeriod.String(),
+			Samples: samples,
+		}
+		glog.Warning(payload.Text)
+		if *alertWebhook == "" {
+			continue
+		}
+		if err := postAlert(*alertWebhook, payload); err != nil {
+			glog.Errorf("Failed to post alert to %s: %v", *alertWebhook, err)
+		}
+	}
+}
+
+// emitter writes hits in one of the supported output formats.
+type emitter interface {
+	emit(hit eslog.Hit) error
+	close() error
+}
+
+type rawEmitter struct{}
+
+func (rawEmitter) emit(hit eslog.Hit) error {
+	// Docker log lines keep their trailing newline in the log field.
+	_, err := fmt.Print(hit.Source.Log)
+	return err
+}
+
+func (rawEmitter) close() error { return nil }
+
+type jsonEmitter struct {
+	encoder *json.Encoder
+}
+
+func (e jsonEmitter) emit(hit eslog.Hit) error { return e.encoder.Encode(hit) }
+func (e jsonEmitter) close() error             { return nil }
+
+type csvEmitter struct {
+	writer *csv.Writer
+}
+
+func (e csvEmitter) emit(hit eslog.Hit) error {
+	return e.writer.Write([]string{
+		hit.Source.Timestamp, hit.Source.PodName, hit.Source.Stream, hit.Source.Tag, hit.Source.Log,
+	})
+}
+
+func (e csvEmitter) close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func newEmitter(format string) (emitter, error) {
+	switch format {
+	case "raw":
+		return rawEmitter{}, nil
+	case "json":
+		return jsonEmitter{encoder: json.NewEncoder(os.Stdout)}, nil
+	case "csv":
+		return csvEmitter{writer: csv.NewWriter(os.Stdout)}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+func main() {
+	flag.Parse()
+
+	if *templateName != "" {
+		if *templatesPath == "" {
+			glog.Fatalf("--template requires --templates")
+		}
+		if err := applyTemplate(*templatesPath, *templateName, *templateParams); err != nil {
+			glog.Fatalf("Bad template: %v", err)
+		}
+	}
+
+	client := eslog.NewClient(*esURL)
+	if *apiserverProxy {
+		var err error
+		client, err = proxyClient()
+		if err != nil {
+			glog.Fatalf("Failed to build API server proxy client: %v", err)
+		}
+	}
+
+	if *verify {
+		if !verifyLogs(client) {
+			os.Exit(1)
+		}
+		return
+	}
+	if *aggregate {
+		aggregateLogs(client)
+		return
+	}
+	if *alert {
+		alertLogs(client)
+		return
+	}
+	if *export != "" {
+		partial, err := exportLogs(client, *export, *exportRegion)
+		if err != nil {
+			glog.Fatalf("Export failed: %v", err)
+		}
+		if partial {
+			os.Exit(1)
+		}
+		return
+	}
+	if *parallel > 0 {
+		parallelSearch(client)
+		return
+	}
+
+	emit, err := chooseEmitter()
+	if err != nil {
+		glog.Fatalf("Bad --output: %v", err)
+	}
+
+	result, err := client.Search(*indices, flagQuery("").Body())
+	if err != nil {
+		glog.Fatalf("Search failed: %v", err)
+	}
+
+	glog.Infof("Total hits: %d", result.Hits.Total)
+	lastSeen := ""
+	for _, hit := range result.Hits.Hits {
+		if err := emit.emit(hit); err != nil {
+			glog.Fatalf("Failed to write output: %v", err)
+		}
+		lastSeen = hit.Source.Timestamp
+	}
+	if err := emit.close(); err != nil {
+		glog.Fatalf("Failed to flush output: %v", err)
+	}
+
+	// In follow mode keep asking for documents newer than the last one we
+	// printed. Transient query failures are logged and retried rather than
+	// terminating the stream.
+	for *follow {
+		time.Sleep(*poll)
+		result, err := client.Search(*indices, flagQuery(lastSeen).Body())
+		if err != nil {
+			glog.Warningf("Search failed: %v", err)
+			continue
+		}
+		for _, hit := range result.Hits.Hits {
+			if err := emit.emit(hit); err != nil {
+				glog.Fatalf("Failed to write output: %v", err)
+			}
+			lastSeen = hit.Source.Timestamp
+		}
+		if err := emit.close(); err != nil {
+			glog.Fatalf("Failed to flush output: %v", err)
+		}
+	}
+}
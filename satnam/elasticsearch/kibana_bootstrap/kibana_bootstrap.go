@@ -0,0 +1,173 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+kibana_bootstrap configures a freshly deployed Kibana/Elasticsearch
+cluster logging stack. It creates the logstash-* index pattern with
+@timestamp as the default time field, marks it as the default index, and
+loads a small set of default visualizations and a dashboard (log volume
+by namespace, error lines by pod). This removes the manual clicking
+otherwise needed after deploying the elasticsearch-kibana addon before
+any logs can be browsed.
+
+Kibana keeps all of this state as documents in the .kibana index, so the
+tool only needs to talk to Elasticsearch.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+var (
+	esURL         = flag.String("elasticsearch", "http://localhost:9200", "URL of the Elasticsearch server behind Kibana")
+	indexPattern  = flag.String("index_pattern", "logstash-*", "Index pattern to create")
+	kibanaVersion = flag.String("kibana_version", "4.0.2", "Kibana version, used as the id of its config document")
+)
+
+// putDocument writes one document into the .kibana index.
+func putDocument(docType, id string, doc map[string]interface{}) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s/%s: %v", docType, id, err)
+	}
+	url := fmt.Sprintf("%s/.kibana/%s/%s", strings.TrimRight(*esURL, "/"), docType, id)
+	req, err := http.NewRequest("PUT", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to put %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	raw, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("put to %s failed with status %d: %s", url, resp.StatusCode, raw)
+	}
+	glog.V(2).Infof("Wrote %s/%s", docType, id)
+	return nil
+}
+
+// visState builds the serialized visState JSON Kibana stores for a terms
+// histogram of log volume split by field.
+func visState(title, field string) string {
+	state := map[string]interface{}{
+		"title": title,
+		"type":  "histogram",
+		"params": map[string]interface{}{
+			"shareYAxis": true, "addTooltip": true, "addLegend": true,
+		},
+		"aggs": []interface{}{
+			map[string]interface{}{
+				"id": "1", "type": "count", "schema": "metric", "params": map[string]interface{}{},
+			},
+			map[string]interface{}{
+				"id": "2", "type": "date_histogram", "schema": "segment",
+				"params": map[string]interface{}{"field": "@timestamp", "interval": "auto"},
+			},
+			map[string]interface{}{
+				"id": "3", "type": "terms", "schema": "group",
+				"params": map[string]interface{}{"field": field, "size": 10, "order": "desc"},
+			},
+		},
+	}
+	data, _ := json.Marshal(state)
+	return string(data)
+}
+
+// searchSource builds the serialized searchSourceJSON for a
+// visualization, optionally restricted by a query string.
+func searchSource(query string) string {
+	source := map[string]interface{}{
+		"index": *indexPattern,
+		"query": map[string]interface{}{
+			"query_string": map[string]interface{}{"query": query, "analyze_wildcard": true},
+		},
+		"filter": []interface{}{},
+	}
+	data, _ := json.Marshal(source)
+	return string(data)
+}
+
+func main() {
+	flag.Parse()
+
+	// The index pattern with its default time field.
+	if err := putDocument("index-pattern", *indexPattern, map[string]interface{}{
+		"title":         *indexPattern,
+		"timeFieldName": "@timestamp",
+	}); err != nil {
+		glog.Fatalf("Failed to create index pattern: %v", err)
+	}
+
+	// Make it the default index so Kibana opens straight onto the logs.
+	if err := putDocument("config", *kibanaVersion, map[string]interface{}{
+		"defaultIndex": *indexPattern,
+	}); err != nil {
+		glog.Fatalf("Failed to set default index: %v", err)
+	}
+
+	// Default visualizations.
+	visualizations := []struct {
+		id    string
+		title string
+		field string
+		query string
+	}{
+		{"log-volume-by-namespace", "Log volume by namespace", "kubernetes_namespace_name", "*"},
+		{"log-volume-by-pod", "Log volume by pod", "kubernetes_pod_name", "*"},
+		{"errors-by-pod", "Error lines by pod", "kubernetes_pod_name", "log:(error OR fail OR fatal)"},
+	}
+	panels := []interface{}{}
+	for i, vis := range visualizations {
+		if err := putDocument("visualization", vis.id, map[string]interface{}{
+			"title":    vis.title,
+			"visState": visState(vis.title, vis.field),
+			"kibanaSavedObjectMeta": map[string]interface{}{
+				"searchSourceJSON": searchSource(vis.query),
+			},
+		}); err != nil {
+			glog.Fatalf("Failed to create visualization %s: %v", vis.id, err)
+		}
+		panels = append(panels, map[string]interface{}{
+			"id": vis.id, "type": "visualization",
+			"size_x": 6, "size_y": 4, "col": 1 + 6*(i%2), "row": 1 + 4*(i/2),
+		})
+	}
+
+	// A dashboard holding the visualizations.
+	panelsJSON, _ := json.Marshal(panels)
+	if err := putDocument("dashboard", "cluster-logging", map[string]interface{}{
+		"title":      "Cluster logging",
+		"panelsJSON": string(panelsJSON),
+	}); err != nil {
+		glog.Fatalf("Failed to create dashboard: %v", err)
+	}
+
+	fmt.Printf("Configured index pattern %s, %d visualizations and the cluster-logging dashboard\n",
+		*indexPattern, len(visualizations))
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
+	"k8s.io/kubernetes/satnam/elasticsearch/eslog"
+)
+
+var (
+	apiserverProxy = flag.Bool("apiserver_proxy", false, "Query Elasticsearch through the Kubernetes API server service proxy instead of connecting directly, authenticated with kubeconfig credentials; --elasticsearch is then ignored")
+	kubeConfig     = flag.String("kubeconfig", "", "Path to the kubeconfig file used with --apiserver_proxy (default ~/.kube/config)")
+	proxyService   = flag.String("proxy_service", "kube-system/elasticsearch-logging:9200", "namespace/name:port of the Elasticsearch service reached through the API server proxy")
+)
+
+// proxyClient builds an eslog client whose requests go through the API
+// server's service proxy, so the Elasticsearch service never has to be
+// exposed outside the cluster. The API server address and credentials
+// come from the kubeconfig file, the same way kubectl finds them.
+func proxyClient() (*eslog.Client, error) {
+	spec := *kubeConfig
+	if spec == "" {
+		spec = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+	settings, err := clientcmd.LoadFromFile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %v", err)
+	}
+	config, err := clientcmd.NewDefaultClientConfig(*settings, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct config: %v", err)
+	}
+	transport, err := client.TransportFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct transport: %v", err)
+	}
+	parts := strings.SplitN(*proxyService, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed --proxy_service %q (expected namespace/name:port)", *proxyService)
+	}
+	esURL := fmt.Sprintf("%s/api/v1/proxy/namespaces/%s/services/%s",
+		strings.TrimRight(config.Host, "/"), parts[0], parts[1])
+	return eslog.NewClientWithTransport(esURL, transport), nil
+}
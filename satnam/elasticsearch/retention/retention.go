@@ -0,0 +1,141 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+retention lists the daily logstash-YYYY.MM.DD indices of a cluster
+logging Elasticsearch instance together with their sizes, and deletes
+indices older than a retention window. Clusters running the
+elasticsearch-kibana addon accumulate an index per day until the disk
+fills and Elasticsearch goes red; running this as a daily job keeps the
+instance healthy. With --dry_run the tool only reports what it would
+delete.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+var (
+	esURL     = flag.String("elasticsearch", "http://localhost:9200", "URL of the Elasticsearch server")
+	retention = flag.Duration("retention", 7*24*time.Hour, "Delete indices whose date is older than this window")
+	dryRun    = flag.Bool("dry_run", false, "Report which indices would be deleted without deleting them")
+)
+
+// indexDateFormat is the date layout embedded in logstash index names.
+const indexDateFormat = "logstash-2006.01.02"
+
+// catIndex is one row of the _cat/indices API response.
+type catIndex struct {
+	Health    string `json:"health"`
+	Index     string `json:"index"`
+	StoreSize string `json:"store.size"`
+}
+
+type byName []catIndex
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Less(i, j int) bool { return b[i].Index < b[j].Index }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// listIndices returns the logstash indices known to the server.
+func listIndices() ([]catIndex, error) {
+	url := fmt.Sprintf("%s/_cat/indices/logstash-*?format=json", *esURL)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("query to %s failed with status %d: %s", url, resp.StatusCode, raw)
+	}
+	var indices []catIndex
+	if err := json.Unmarshal(raw, &indices); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+	return indices, nil
+}
+
+// deleteIndex removes a single index.
+func deleteIndex(name string) error {
+	url := fmt.Sprintf("%s/%s", *esURL, name)
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	raw, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("delete of %s failed with status %d: %s", url, resp.StatusCode, raw)
+	}
+	return nil
+}
+
+func main() {
+	flag.Parse()
+
+	indices, err := listIndices()
+	if err != nil {
+		glog.Fatalf("Failed to list indices: %v", err)
+	}
+	sort.Sort(byName(indices))
+
+	cutoff := time.Now().Add(-*retention)
+	glog.Infof("Deleting indices older than %s", cutoff.Format("2006.01.02"))
+
+	deleted, kept := 0, 0
+	for _, index := range indices {
+		date, err := time.Parse(indexDateFormat, index.Index)
+		if err != nil {
+			glog.Warningf("Skipping index %s with unparseable date: %v", index.Index, err)
+			continue
+		}
+		if !date.Before(cutoff) {
+			fmt.Printf("keep   %s %8s %s\n", index.Index, index.StoreSize, index.Health)
+			kept++
+			continue
+		}
+		if *dryRun {
+			fmt.Printf("would delete %s %8s %s\n", index.Index, index.StoreSize, index.Health)
+			deleted++
+			continue
+		}
+		if err := deleteIndex(index.Index); err != nil {
+			glog.Errorf("Failed to delete index %s: %v", index.Index, err)
+			continue
+		}
+		fmt.Printf("deleted %s %8s\n", index.Index, index.StoreSize)
+		deleted++
+	}
+	glog.Infof("Kept %d indices, deleted %d (dry run: %v)", kept, deleted, *dryRun)
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+synthlogger launches pods that emit uniquely numbered log lines at a
+configurable rate and line size, and writes a manifest describing the
+run. Each line has the form
+
+    <pod index> <taint> <line number> <pod name> <padding>
+
+which is the format expected by getlogs --verify, so a complete
+end-to-end check of the logging pipeline is:
+
+    synthlogger --pods=10 --lines=1000 --manifest=run.json
+    getlogs --verify --verify_pods=10 --verify_lines=1000 --taint=<taint from manifest>
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/pkg/api"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
+)
+
+var (
+	pods         = flag.Int("pods", 1, "Number of synthetic logger pods to run")
+	lines        = flag.Int("lines", 100, "Number of numbered lines each pod emits")
+	linesPerSec  = flag.Int("lines_per_sec", 10, "Rate at which each pod emits lines")
+	lineSize     = flag.Int("line_size", 0, "Pad each line with spaces to at least this many bytes")
+	namespace    = flag.String("namespace", "", "Namespace to run in; one is created if empty")
+	manifestPath = flag.String("manifest", "synthlogger.json", "File to write the run manifest to")
+	wait         = flag.Bool("wait", true, "Wait for all logger pods to complete before exiting")
+	gke          = flag.String("gke_context", "", "Target GKE cluster with context gke_{project}_{zone}_{cluster-name}")
+)
+
+const (
+	podCompleteTimeout = 1 * time.Hour
+	pollInterval       = 5 * time.Second
+)
+
+// manifest records what a synthetic logger run produced so that a later
+// verification pass knows what to look for.
+type manifest struct {
+	Taint       string    `json:"taint"`
+	Namespace   string    `json:"namespace"`
+	Pods        int       `json:"pods"`
+	Lines       int       `json:"lines"`
+	LinesPerSec int       `json:"linesPerSec"`
+	LineSize    int       `json:"lineSize"`
+	PodNames    []string  `json:"podNames"`
+	Start       time.Time `json:"start"`
+}
+
+func makeClient() (*client.Client, error) {
+	var spec string
+	if *gke != "" {
+		spec = filepath.Join(os.Getenv("HOME"), ".config", "gcloud", "kubernetes", "kubeconfig")
+	} else {
+		spec = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+	settings, err := clientcmd.LoadFromFile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %v", err)
+	}
+	if *gke != "" {
+		settings.CurrentContext = *gke
+	}
+	config, err := clientcmd.NewDefaultClientConfig(*settings, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct config: %v", err)
+	}
+	return client.New(config)
+}
+
+// loggerCommand builds the shell loop a logger pod runs. The pod writes
+// count numbered lines at rate lines per second, each line padded to at
+// least size bytes.
+func loggerCommand(podIndex int, taint, podName string, count, rate, size int) string {
+	padding := ""
+	if size > 0 {
+		padding = " " + strings.Repeat("x", size)
+	}
+	// Sleeping 1/rate seconds per line approximates the requested rate
+	// closely enough for pipeline testing.
+	sleep := fmt.Sprintf("%.3f", 1.0/float64(rate))
+	return fmt.Sprintf(
+		"i=0; while ((i < %d)); do echo \"%d %s $i %s%s\"; sleep %s; i=$(($i+1)); done",
+		count, podIndex, taint, podName, padding, sleep)
+}
+
+func main() {
+	flag.Parse()
+
+	c, err := makeClient()
+	if err != nil {
+		glog.Fatalf("Failed to make client: %v", err)
+	}
+
+	ns := *namespace
+	if ns == "" {
+		got, err := c.Namespaces().Create(&api.Namespace{ObjectMeta: api.ObjectMeta{GenerateName: "synthlogger-"}})
+		if err != nil {
+			glog.Fatalf("Failed to create namespace: %v", err)
+		}
+		ns = got.Name
+		glog.Infof("Created namespace %s", ns)
+	}
+
+	// The taint makes the lines of this run unique so concurrent or
+	// repeated runs do not pollute each other's verification.
+	taint := strings.Replace(fmt.Sprintf("synthlgr%s%d", ns, time.Now().Unix()), "-", "_", -1)
+	glog.Infof("Tainting log lines with %s", taint)
+
+	m := manifest{
+		Taint:       taint,
+		Namespace:   ns,
+		Pods:        *pods,
+		Lines:       *lines,
+		LinesPerSec: *linesPerSec,
+		LineSize:    *lineSize,
+		Start:       time.Now(),
+	}
+	for i := 0; i < *pods; i++ {
+		podName := fmt.Sprintf("synthlogger-%d", i)
+		_, err := c.Pods(ns).Create(&api.Pod{
+			ObjectMeta: api.ObjectMeta{
+				Name:   podName,
+				Labels: map[string]string{"name": "synthlogger"},
+			},
+			Spec: api.PodSpec{
+				Containers: []api.Container{
+					{
+						Name:    "synth-logger",
+						Image:   "gcr.io/google_containers/ubuntu:14.04",
+						Command: []string{"bash", "-c", loggerCommand(i, taint, podName, *lines, *linesPerSec, *lineSize)},
+					},
+				},
+				RestartPolicy: api.RestartPolicyNever,
+			},
+		})
+		if err != nil {
+			glog.Fatalf("Failed to create pod %s/%s: %v", ns, podName, err)
+		}
+		m.PodNames = append(m.PodNames, podName)
+	}
+	glog.Infof("Created %d logger pods in namespace %s", *pods, ns)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		glog.Fatalf("Failed to marshal manifest: %v", err)
+	}
+	if err := ioutil.WriteFile(*manifestPath, data, 0644); err != nil {
+		glog.Fatalf("Failed to write manifest %s: %v", *manifestPath, err)
+	}
+	glog.Infof("Wrote manifest to %s", *manifestPath)
+
+	if !*wait {
+		return
+	}
+	// Wait for every logger pod to run to completion.
+	completed := map[string]bool{}
+	for start := time.Now(); time.Since(start) < podCompleteTimeout; time.Sleep(pollInterval) {
+		for _, podName := range m.PodNames {
+			if completed[podName] {
+				continue
+			}
+			pod, err := c.Pods(ns).Get(podName)
+			if err != nil {
+				glog.Warningf("Failed to get pod %s/%s: %v", ns, podName, err)
+				continue
+			}
+			switch pod.Status.Phase {
+			case api.PodSucceeded:
+				completed[podName] = true
+			case api.PodFailed:
+				glog.Fatalf("Pod %s/%s failed", ns, podName)
+			}
+		}
+		if len(completed) == len(m.PodNames) {
+			glog.Infof("All %d logger pods completed", len(completed))
+			return
+		}
+	}
+	glog.Fatalf("Only %d of %d logger pods completed before timeout", len(completed), len(m.PodNames))
+}
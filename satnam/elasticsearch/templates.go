@@ -0,0 +1,145 @@
+/*
+Copyright 2015 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	templatesPath  = flag.String("templates", "", "Path to a JSON file of named query templates that teams can share")
+	templateName   = flag.String("template", "", "Name of a query template from --templates to apply")
+	templateParams = flag.String("params", "", "Comma separated name=value parameters substituted into ${name} placeholders in the template")
+)
+
+// queryTemplate is one entry in a templates file. Every field is
+// optional and overrides the matching query flag; string fields may
+// contain ${name} placeholders filled in from --params, and the since
+// and until fields are durations in the same format as the flags. A
+// templates file looks like:
+//
+//	{
+//	    "errors-for-pod": {
+//	        "field": "kubernetes_pod_name",
+//	        "term": "${pod}",
+//	        "query": "error",
+//	        "since": "${window}"
+//	    }
+//	}
+type queryTemplate struct {
+	Field string `json:"field"`
+	Term  string `json:"term"`
+	Query string `json:"query"`
+	Node  string `json:"node"`
+	Since string `json:"since"`
+	Until string `json:"until"`
+	Size  int    `json:"size"`
+}
+
+var templateParamRE = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// applyTemplate loads the named template and applies it by overriding
+// the corresponding query flags, so a template composes with every mode
+// (--follow, --aggregate, --export, ...) without further plumbing.
+func applyTemplate(path, name, params string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read templates file: %v", err)
+	}
+	templates := map[string]queryTemplate{}
+	if err := json.Unmarshal(raw, &templates); err != nil {
+		return fmt.Errorf("failed to parse templates file %s: %v", path, err)
+	}
+	template, ok := templates[name]
+	if !ok {
+		names := []string{}
+		for n := range templates {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("no template %q; %s defines: %s", name, path, strings.Join(names, ", "))
+	}
+
+	values := map[string]string{}
+	if params != "" {
+		for _, pair := range strings.Split(params, ",") {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("malformed parameter %q (expected name=value)", pair)
+			}
+			values[parts[0]] = parts[1]
+		}
+	}
+
+	// Unknown placeholders are an error rather than an empty
+	// substitution: a template silently matching everything is worse
+	// than failing fast.
+	var missing []string
+	expand := func(s string) string {
+		return templateParamRE.ReplaceAllStringFunc(s, func(placeholder string) string {
+			param := templateParamRE.FindStringSubmatch(placeholder)[1]
+			value, ok := values[param]
+			if !ok {
+				missing = append(missing, param)
+			}
+			return value
+		})
+	}
+
+	if template.Field != "" {
+		*field = expand(template.Field)
+	}
+	if template.Term != "" {
+		*term = expand(template.Term)
+	}
+	if template.Query != "" {
+		*query = expand(template.Query)
+	}
+	if template.Node != "" {
+		*node = expand(template.Node)
+	}
+	if template.Since != "" {
+		d, err := time.ParseDuration(expand(template.Since))
+		if err != nil {
+			return fmt.Errorf("bad since duration in template %q: %v", name, err)
+		}
+		*since = d
+	}
+	if template.Until != "" {
+		d, err := time.ParseDuration(expand(template.Until))
+		if err != nil {
+			return fmt.Errorf("bad until duration in template %q: %v", name, err)
+		}
+		*until = d
+	}
+	if template.Size != 0 {
+		*size = template.Size
+	}
+
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("template %q needs --params values for: %s", name, strings.Join(missing, ", "))
+	}
+	return nil
+}
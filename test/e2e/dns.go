@@ -125,6 +125,48 @@ func createProbeCommand(namesToResolve []string, fileNamePrefix string) (string,
 	return probeCmd, fileNames
 }
 
+// createExpectationProbeCommand builds a probe loop that records OK both
+// for names expected to resolve and for names expected to stay
+// unresolvable, so a single prober can assert both halves of a
+// dnsPolicy's behavior.
+func createExpectationProbeCommand(resolvable, unresolvable []string, fileNamePrefix string) (string, []string) {
+	fileNames := make([]string, 0, len(resolvable)+len(unresolvable))
+	probeCmd := "for i in `seq 1 600`; do "
+	for _, name := range resolvable {
+		fileName := fmt.Sprintf("%s_resolvable@%s", fileNamePrefix, name)
+		fileNames = append(fileNames, fileName)
+		probeCmd += fmt.Sprintf(`test -n "$$(dig +notcp +noall +answer %s A)" && echo OK > /results/%s;`, name, fileName)
+	}
+	for _, name := range unresolvable {
+		fileName := fmt.Sprintf("%s_unresolvable@%s", fileNamePrefix, name)
+		fileNames = append(fileNames, fileName)
+		probeCmd += fmt.Sprintf(`test -z "$$(dig +notcp +noall +answer %s A)" && echo OK > /results/%s;`, name, fileName)
+	}
+	probeCmd += "sleep 1; done"
+	return probeCmd, fileNames
+}
+
+// createTCPFallbackProbeCommand builds a probe loop for a name whose record
+// set no longer fits in a 512-byte UDP response: the UDP answer must come
+// back truncated (TC bit set), an explicit TCP query must return at least
+// minRecords records, and a default query must end up with the full set by
+// honoring the truncation and retrying over TCP.
+func createTCPFallbackProbeCommand(name string, minRecords int, fileNamePrefix string) (string, []string) {
+	fileNames := []string{
+		fmt.Sprintf("%s_truncated@%s", fileNamePrefix, name),
+		fmt.Sprintf("%s_tcp_full@%s", fileNamePrefix, name),
+		fmt.Sprintf("%s_fallback@%s", fileNamePrefix, name),
+	}
+	probeCmd := "for i in `seq 1 600`; do "
+	// +ignore stops dig from retrying over TCP itself, so the TC bit of the
+	// raw UDP response stays observable.
+	probeCmd += fmt.Sprintf(`test -n "$$(dig +notcp +ignore +search %s A | grep ' tc[ ;]')" && echo OK > /results/%s;`, name, fileNames[0])
+	probeCmd += fmt.Sprintf(`test "$$(dig +tcp +noall +answer +search %s A | wc -l)" -ge %d && echo OK > /results/%s;`, name, minRecords, fileNames[1])
+	probeCmd += fmt.Sprintf(`test "$$(dig +noall +answer +search %s A | wc -l)" -ge %d && echo OK > /results/%s;`, name, minRecords, fileNames[2])
+	probeCmd += "sleep 1; done"
+	return probeCmd, fileNames
+}
+
 func assertFilesExist(fileNames []string, fileDir string, pod *api.Pod, client *client.Client) {
 	var failed []string
 
@@ -322,4 +364,139 @@ var _ = Describe("DNS", func() {
 		validateDNSResults(f, pod, append(wheezyFileNames, jessieFileNames...))
 	})
 
+	It("should fall back to TCP for headless service responses exceeding 512 bytes", func() {
+		// TODO: support DNS on vagrant #3580
+		SkipIfProviderIs("vagrant")
+
+		systemClient := f.Client.Pods(api.NamespaceSystem)
+		By("Waiting for DNS Service to be Running")
+		options := api.ListOptions{LabelSelector: dnsServiceLableSelector}
+		dnsPods, err := systemClient.List(options)
+		if err != nil {
+			Failf("Failed to list all dns service pods")
+		}
+		if len(dnsPods.Items) != 1 {
+			Failf("Unexpected number of pods (%d) matches the label selector %v", len(dnsPods.Items), dnsServiceLableSelector.String())
+		}
+		expectNoError(waitForPodRunningInNamespace(f.Client, dnsPods.Items[0].Name, api.NamespaceSystem))
+
+		// Enough endpoints that the headless service's A record set
+		// overflows a 512-byte UDP response.
+		const backends = 40
+
+		By("Creating a test headless service with many endpoints")
+		testServiceSelector := map[string]string{
+			"dns-test-large": "true",
+		}
+		headlessService := &api.Service{
+			ObjectMeta: api.ObjectMeta{
+				Name: "test-service-large",
+			},
+			Spec: api.ServiceSpec{
+				ClusterIP: "None",
+				Ports: []api.ServicePort{
+					{Port: 80, Name: "http", Protocol: "TCP"},
+				},
+				Selector: testServiceSelector,
+			},
+		}
+		_, err = f.Client.Services(f.Namespace.Name).Create(headlessService)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() {
+			By("deleting the test headless service")
+			defer GinkgoRecover()
+			f.Client.Services(f.Namespace.Name).Delete(headlessService.Name)
+		}()
+
+		By(fmt.Sprintf("Creating %d backend pods for the headless service", backends))
+		for i := 0; i < backends; i++ {
+			_, err := f.Client.Pods(f.Namespace.Name).Create(&api.Pod{
+				ObjectMeta: api.ObjectMeta{
+					Name:   fmt.Sprintf("dns-backend-%d", i),
+					Labels: testServiceSelector,
+				},
+				Spec: api.PodSpec{
+					Containers: []api.Container{
+						{
+							Name:  "backend",
+							Image: "gcr.io/google_containers/pause:2.0",
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		defer func() {
+			By("deleting the backend pods")
+			defer GinkgoRecover()
+			for i := 0; i < backends; i++ {
+				f.Client.Pods(f.Namespace.Name).Delete(fmt.Sprintf("dns-backend-%d", i), api.NewDeleteOptions(0))
+			}
+		}()
+		for i := 0; i < backends; i++ {
+			expectNoError(f.WaitForPodRunning(fmt.Sprintf("dns-backend-%d", i)))
+		}
+
+		serviceName := fmt.Sprintf("%s.%s.svc", headlessService.Name, f.Namespace.Name)
+		wheezyProbeCmd, wheezyFileNames := createTCPFallbackProbeCommand(serviceName, backends, "wheezy")
+		jessieProbeCmd, jessieFileNames := createTCPFallbackProbeCommand(serviceName, backends, "jessie")
+
+		By("creating a pod to probe DNS truncation and TCP fallback")
+		pod := createDNSPod(f.Namespace.Name, wheezyProbeCmd, jessieProbeCmd)
+		validateDNSResults(f, pod, append(wheezyFileNames, jessieFileNames...))
+	})
+
+	It("should provide cluster DNS only under dnsPolicy ClusterFirst", func() {
+		// TODO: support DNS on vagrant #3580
+		SkipIfProviderIs("vagrant")
+
+		systemClient := f.Client.Pods(api.NamespaceSystem)
+		By("Waiting for DNS Service to be Running")
+		options := api.ListOptions{LabelSelector: dnsServiceLableSelector}
+		dnsPods, err := systemClient.List(options)
+		if err != nil {
+			Failf("Failed to list all dns service pods")
+		}
+		if len(dnsPods.Items) != 1 {
+			Failf("Unexpected number of pods (%d) matches the label selector %v", len(dnsPods.Items), dnsServiceLableSelector.String())
+		}
+		expectNoError(waitForPodRunningInNamespace(f.Client, dnsPods.Items[0].Name, api.NamespaceSystem))
+
+		// Under ClusterFirst both cluster and upstream names resolve.
+		clusterNames := []string{
+			"kubernetes.default.svc.cluster.local",
+		}
+		upstreamNames := []string{"google.com"}
+
+		wheezyProbeCmd, wheezyFileNames := createExpectationProbeCommand(append(upstreamNames, clusterNames...), nil, "wheezy")
+		jessieProbeCmd, jessieFileNames := createExpectationProbeCommand(append(upstreamNames, clusterNames...), nil, "jessie")
+
+		By("creating a pod with dnsPolicy ClusterFirst to probe DNS")
+		pod := createDNSPod(f.Namespace.Name, wheezyProbeCmd, jessieProbeCmd)
+		pod.Spec.DNSPolicy = api.DNSClusterFirst
+		validateDNSResults(f, pod, append(wheezyFileNames, jessieFileNames...))
+	})
+
+	It("should not provide cluster DNS under dnsPolicy Default", func() {
+		// TODO: support DNS on vagrant #3580
+		SkipIfProviderIs("vagrant")
+
+		// Under Default the pod inherits the node's resolv.conf:
+		// upstream names keep resolving but cluster names must not. A
+		// regression in resolv.conf generation typically leaves cluster
+		// search paths behind, which this catches.
+		clusterNames := []string{
+			"kubernetes.default.svc.cluster.local",
+		}
+		upstreamNames := []string{"google.com"}
+
+		wheezyProbeCmd, wheezyFileNames := createExpectationProbeCommand(upstreamNames, clusterNames, "wheezy")
+		jessieProbeCmd, jessieFileNames := createExpectationProbeCommand(upstreamNames, clusterNames, "jessie")
+
+		By("creating a pod with dnsPolicy Default to probe DNS")
+		pod := createDNSPod(f.Namespace.Name, wheezyProbeCmd, jessieProbeCmd)
+		pod.Spec.DNSPolicy = api.DNSDefault
+		validateDNSResults(f, pod, append(wheezyFileNames, jessieFileNames...))
+	})
+
 })
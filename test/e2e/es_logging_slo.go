@@ -0,0 +1,168 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package e2e
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/satnam/elasticsearch/eslog"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cluster level logging ingestion SLO [Feature:Elasticsearch]", func() {
+	f := NewFramework("es-logging-slo")
+
+	BeforeEach(func() {
+		// The Elasticsearch addon is only deployed by default on GCE.
+		SkipUnlessProviderIs("gce")
+	})
+
+	It("should ingest every synthetic log line into Elasticsearch within the SLO", func() {
+		ClusterLoggingIngestionSLO(f)
+	})
+})
+
+// ingestionSLO is how long after the synthetic loggers finish that every
+// line they emitted must be queryable in Elasticsearch.
+const ingestionSLO = 5 * time.Minute
+
+// ClusterLoggingIngestionSLO launches a synthetic logger pod on each
+// node, waits for them to finish, then uses the eslog library through
+// the API server service proxy to verify that every emitted line was
+// ingested within the SLO.
+func ClusterLoggingIngestionSLO(f *Framework) {
+	// linesPerPod is the number of numbered log lines each pod emits.
+	const linesPerPod = 100
+
+	nodes, err := f.Client.Nodes().List(api.ListOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(len(nodes.Items)).NotTo(BeZero())
+
+	ns := f.Namespace.Name
+	name := "synthlogger"
+	// Replace '-' characters with '_' to prevent the analyzer from
+	// breaking apart names.
+	taintName := strings.Replace(ns+name, "-", "_", -1)
+	Logf("Tainting log lines with %v", taintName)
+
+	By("Creating a synthetic logger pod on each node")
+	var podNames []string
+	for i, node := range nodes.Items {
+		podName := fmt.Sprintf("%s-%d", name, i)
+		_, err := f.Client.Pods(ns).Create(&api.Pod{
+			ObjectMeta: api.ObjectMeta{
+				Name:   podName,
+				Labels: map[string]string{"name": name},
+			},
+			Spec: api.PodSpec{
+				Containers: []api.Container{
+					{
+						Name:  "synth-logger",
+						Image: "gcr.io/google_containers/ubuntu:14.04",
+						// notice: the subshell syntax is escaped with `$$`
+						Command: []string{"bash", "-c", fmt.Sprintf("i=0; while ((i < %d)); do echo \"%d %s $i %s\"; i=$$(($i+1)); done", linesPerPod, i, taintName, podName)},
+					},
+				},
+				NodeName:      node.Name,
+				RestartPolicy: api.RestartPolicyNever,
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		podNames = append(podNames, podName)
+	}
+	defer func() {
+		for _, pod := range podNames {
+			if err := f.Client.Pods(ns).Delete(pod, nil); err != nil {
+				Logf("Failed to delete pod %s: %v", pod, err)
+			}
+		}
+	}()
+
+	By("Waiting for the pods to succeed")
+	for _, pod := range podNames {
+		err = waitForPodSuccessInNamespace(f.Client, pod, "synth-logger", ns)
+		Expect(err).NotTo(HaveOccurred())
+	}
+	finished := time.Now()
+
+	By("Building an eslog client that talks through the API server proxy")
+	config, err := loadConfig()
+	Expect(err).NotTo(HaveOccurred())
+	transport, err := client.TransportFor(config)
+	Expect(err).NotTo(HaveOccurred())
+	esURL := fmt.Sprintf("%s/api/v1/proxy/namespaces/%s/services/elasticsearch-logging:9200",
+		strings.TrimRight(config.Host, "/"), api.NamespaceSystem)
+	esClient := eslog.NewClientWithTransport(esURL, transport)
+
+	By("Checking that every line arrives within the ingestion SLO")
+	expected := len(podNames) * linesPerPod
+	deadline := finished.Add(ingestionSLO)
+	for {
+		missing := countMissingLines(esClient, taintName, len(podNames), linesPerPod)
+		if missing == 0 {
+			Logf("All %d lines ingested %v after the loggers finished", expected, time.Since(finished))
+			return
+		}
+		if time.Now().After(deadline) {
+			Failf("%d of %d log lines were not ingested within the %v SLO", missing, expected, ingestionSLO)
+		}
+		Logf("Still missing %d of %d lines after %v", missing, expected, time.Since(finished))
+		time.Sleep(10 * time.Second)
+	}
+}
+
+// countMissingLines queries Elasticsearch for the tainted lines and
+// returns how many of the expected numbered lines have not arrived yet.
+func countMissingLines(esClient *eslog.Client, taintName string, pods, linesPerPod int) int {
+	hits, err := esClient.SearchAll("logstash-*", eslog.Query{Text: taintName}, 1000)
+	if err != nil {
+		Logf("Search for tainted lines failed: %v", err)
+		return pods * linesPerPod
+	}
+	observed := make([][]bool, pods)
+	for i := range observed {
+		observed[i] = make([]bool, linesPerPod)
+	}
+	for _, hit := range hits {
+		words := strings.Fields(hit.Source.Log)
+		if len(words) != 4 || words[1] != taintName {
+			continue
+		}
+		pod, err1 := strconv.Atoi(words[0])
+		line, err2 := strconv.Atoi(words[2])
+		if err1 != nil || err2 != nil || pod < 0 || pod >= pods || line < 0 || line >= linesPerPod {
+			continue
+		}
+		observed[pod][line] = true
+	}
+	missing := 0
+	for pod := range observed {
+		for _, seen := range observed[pod] {
+			if !seen {
+				missing++
+			}
+		}
+	}
+	return missing
+}
@@ -30,6 +30,7 @@ import (
 	client "k8s.io/kubernetes/pkg/client/unversioned"
 	"k8s.io/kubernetes/pkg/fields"
 	"k8s.io/kubernetes/pkg/metrics"
+	"k8s.io/kubernetes/pkg/util/wait"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -344,6 +345,42 @@ func (f *Framework) ReadFileViaContainer(podName, containerName string, path str
 	return string(stdout), err
 }
 
+// ExecCommandInContainer executes a command in the named container of a
+// running pod and returns its stdout and stderr. It lets tests probe
+// interactively (e.g. run dig or curl and inspect the output) instead
+// of baking long shell loops into the pod command and polling a
+// results webserver.
+func (f *Framework) ExecCommandInContainer(podName, containerName string, cmd ...string) (string, string, error) {
+	args := append([]string{"--"}, cmd...)
+	stdout, stderr, err := kubectlExecWithRetry(f.Namespace.Name, podName, containerName, args...)
+	return string(stdout), string(stderr), err
+}
+
+// ExecShellInContainer runs the given command through a shell in the
+// named container.
+func (f *Framework) ExecShellInContainer(podName, containerName, cmd string) (string, string, error) {
+	return f.ExecCommandInContainer(podName, containerName, "/bin/sh", "-c", cmd)
+}
+
+// ProbeCommandInContainer retries the command in the container until it
+// exits zero and its stdout satisfies the predicate (which may be nil),
+// or the timeout expires. Failed attempts are logged, so a failing
+// probe leaves the observed output in the test log.
+func (f *Framework) ProbeCommandInContainer(podName, containerName string, timeout time.Duration, predicate func(stdout string) bool, cmd ...string) error {
+	return wait.Poll(2*time.Second, timeout, func() (bool, error) {
+		stdout, stderr, err := f.ExecCommandInContainer(podName, containerName, cmd...)
+		if err != nil {
+			Logf("Probe %v in %s/%s failed: %v\nstdout=%v\nstderr=%v", cmd, podName, containerName, err, stdout, stderr)
+			return false, nil
+		}
+		if predicate != nil && !predicate(stdout) {
+			Logf("Probe %v in %s/%s returned unexpected output:\n%v", cmd, podName, containerName, stdout)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
 func kubectlExecWithRetry(namespace string, podName, containerName string, args ...string) ([]byte, []byte, error) {
 	for numRetries := 0; numRetries < maxKubectlExecRetries; numRetries++ {
 		if numRetries > 0 {
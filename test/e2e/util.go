@@ -2410,11 +2410,17 @@ func NodeSSHHosts(c *client.Client) ([]string, error) {
 	// TODO(roberthbailey): Use the "preferred" address for the node, once such a thing is defined (#2462).
 	hosts := NodeAddresses(nodelist, api.NodeExternalIP)
 
-	// Error if any node didn't have an external IP.
+	// Error if any node didn't have an external IP, unless a bastion is
+	// configured: tunneled traffic can reach the internal IPs instead.
 	if len(hosts) != len(nodelist.Items) {
-		return hosts, fmt.Errorf(
-			"only found %d external IPs on nodes, but found %d nodes. Nodelist: %v",
-			len(hosts), len(nodelist.Items), nodelist)
+		if sshBastion() != "" {
+			hosts = NodeAddresses(nodelist, api.NodeInternalIP)
+		}
+		if len(hosts) != len(nodelist.Items) {
+			return hosts, fmt.Errorf(
+				"only found %d external IPs on nodes, but found %d nodes. Nodelist: %v",
+				len(hosts), len(nodelist.Items), nodelist)
+		}
 	}
 
 	sshHosts := make([]string, 0, len(hosts))
@@ -2452,7 +2458,13 @@ func SSH(cmd, host, provider string) (SSHResult, error) {
 		result.User = os.Getenv("USER")
 	}
 
-	stdout, stderr, code, err := sshutil.RunSSHCommand(cmd, result.User, host, signer)
+	var stdout, stderr string
+	var code int
+	if bastion := sshBastion(); bastion != "" {
+		stdout, stderr, code, err = runSSHCommandViaBastion(cmd, result.User, bastion, host, signer)
+	} else {
+		stdout, stderr, code, err = sshutil.RunSSHCommand(cmd, result.User, host, signer)
+	}
 	result.Stdout = stdout
 	result.Stderr = stderr
 	result.Code = code
@@ -2460,6 +2472,99 @@ func SSH(cmd, host, provider string) (SSHResult, error) {
 	return result, err
 }
 
+// sshBastion returns the bastion through which SSH traffic to nodes must be
+// tunneled, from the KUBE_SSH_BASTION environment variable (host or
+// host:port; port 22 is assumed). Empty means nodes are dialed directly.
+// Configuring a bastion lets host-probing tests run against clusters whose
+// nodes have no public IPs, e.g. typical AWS VPC setups.
+func sshBastion() string {
+	bastion := os.Getenv("KUBE_SSH_BASTION")
+	if bastion == "" {
+		return ""
+	}
+	if _, _, err := net.SplitHostPort(bastion); err != nil {
+		bastion = net.JoinHostPort(bastion, "22")
+	}
+	return bastion
+}
+
+// dialViaBastion opens a TCP connection to addr (e.g. a NodePort on a node's
+// internal IP) tunneled through the SSH bastion. The caller must close both
+// the returned connection and the bastion client keeping it alive.
+func dialViaBastion(addr string) (net.Conn, io.Closer, error) {
+	signer, err := getSigner(testContext.Provider)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting signer for provider %s: '%v'", testContext.Provider, err)
+	}
+	user := os.Getenv("KUBE_SSH_USER")
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	bastion := sshBastion()
+	config := &ssh.ClientConfig{
+		User: user,
+		Auth: []ssh.AuthMethod{ssh.PublicKeys(signer)},
+	}
+	bastionClient, err := ssh.Dial("tcp", bastion, config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error dialing bastion %s@%s: '%v'", user, bastion, err)
+	}
+	conn, err := bastionClient.Dial("tcp", addr)
+	if err != nil {
+		bastionClient.Close()
+		return nil, nil, fmt.Errorf("error dialing %s via bastion %s: '%v'", addr, bastion, err)
+	}
+	return conn, bastionClient, nil
+}
+
+// runSSHCommandViaBastion runs cmd on host by opening an SSH connection to
+// the bastion and nesting an SSH session to the target over it. The same key
+// is used for both hops.
+func runSSHCommandViaBastion(cmd, user, bastion, host string, signer ssh.Signer) (string, string, int, error) {
+	config := &ssh.ClientConfig{
+		User: user,
+		Auth: []ssh.AuthMethod{ssh.PublicKeys(signer)},
+	}
+	bastionClient, err := ssh.Dial("tcp", bastion, config)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("error dialing bastion %s@%s: '%v'", user, bastion, err)
+	}
+	defer bastionClient.Close()
+
+	conn, err := bastionClient.Dial("tcp", host)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("error dialing %s via bastion %s: '%v'", host, bastion, err)
+	}
+	ncc, chans, reqs, err := ssh.NewClientConn(conn, host, config)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("error creating SSH connection to %s@%s via bastion: '%v'", user, host, err)
+	}
+	client := ssh.NewClient(ncc, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", "", 0, fmt.Errorf("error creating session to %s@%s via bastion: '%v'", user, host, err)
+	}
+	defer session.Close()
+
+	// Run the command, distinguishing a remote nonzero exit from an SSH
+	// failure the same way sshutil.RunSSHCommand does.
+	code := 0
+	var bout, berr bytes.Buffer
+	session.Stdout, session.Stderr = &bout, &berr
+	if err = session.Run(cmd); err != nil {
+		if exiterr, ok := err.(*ssh.ExitError); ok {
+			if code = exiterr.ExitStatus(); code != 0 {
+				err = nil
+			}
+		} else {
+			err = fmt.Errorf("failed running `%s` on %s@%s: '%v'", cmd, user, host, err)
+		}
+	}
+	return bout.String(), berr.String(), code, err
+}
+
 func LogSSHResult(result SSHResult) {
 	remote := fmt.Sprintf("%s@%s", result.User, result.Host)
 	Logf("ssh %s: command:   %s", remote, result.Cmd)
@@ -0,0 +1,252 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+This tool measures the end-user-visible latency of the operations people
+run against a cluster all day: getting a pod, listing pods, the get plus
+event search a describe performs, fetching logs and executing a command.
+The raw create/list benchmarks characterize the apiserver write path;
+this one reports percentiles per verb for the interactive read paths, so
+regressions that make a loaded cluster feel slow are visible even when
+bulk throughput looks fine.
+*/
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
+	"k8s.io/kubernetes/pkg/client/unversioned/remotecommand"
+)
+
+var (
+	podCount = flag.Int("pods", 10, "Number of pods the operations are spread across")
+	samples  = flag.Int("samples", 100, "Number of samples collected per verb")
+	gke      = flag.String("gke_context", "", "Target GKE cluster with context gke_{project}_{zone}_{cluster-name}")
+)
+
+const (
+	podRunningTimeout      = 5 * time.Minute
+	namespaceDeleteTimeout = 5 * time.Minute
+	pollInterval           = 2 * time.Second
+)
+
+func makeClient() (*client.Client, *client.Config, error) {
+	var spec string
+	if *gke != "" {
+		spec = filepath.Join(os.Getenv("HOME"), ".config", "gcloud", "kubernetes", "kubeconfig")
+	} else {
+		spec = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+	settings, err := clientcmd.LoadFromFile(spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error loading configuration: %v", err)
+	}
+	if *gke != "" {
+		settings.CurrentContext = *gke
+	}
+	config, err := clientcmd.NewDefaultClientConfig(*settings, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to construct config: %v", err)
+	}
+	c, err := client.New(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, config, nil
+}
+
+// verb measures one kind of client operation against a named pod.
+type verb struct {
+	name string
+	do   func(name string) error
+}
+
+func percentiles(name string, elapsed []time.Duration) {
+	if len(elapsed) == 0 {
+		fmt.Printf("%-12s no samples\n", name)
+		return
+	}
+	sort.Sort(durations(elapsed))
+	fmt.Printf("%-12s n=%d p50=%v p90=%v p99=%v max=%v\n",
+		name,
+		len(elapsed),
+		elapsed[len(elapsed)*50/100],
+		elapsed[len(elapsed)*90/100],
+		elapsed[min(len(elapsed)*99/100, len(elapsed)-1)],
+		elapsed[len(elapsed)-1])
+}
+
+func main() {
+	flag.Parse()
+
+	glog.Infof("Starting client_latency with pods=%d samples=%d", *podCount, *samples)
+
+	c, config, err := makeClient()
+	if err != nil {
+		glog.Fatalf("Failed to make client: %v", err)
+	}
+
+	got, err := c.Namespaces().Create(&api.Namespace{ObjectMeta: api.ObjectMeta{GenerateName: "client-latency-"}})
+	if err != nil {
+		glog.Fatalf("Failed to create namespace: %v", err)
+	}
+	ns := got.Name
+	defer func(ns string) {
+		if err := c.Namespaces().Delete(ns); err != nil {
+			glog.Warningf("Failed to delete namespace %s: %v", ns, err)
+		} else {
+			// wait until the namespace disappears
+			for i := 0; i < int(namespaceDeleteTimeout/time.Second); i++ {
+				if _, err := c.Namespaces().Get(ns); err != nil {
+					if errors.IsNotFound(err) {
+						return
+					}
+				}
+				time.Sleep(time.Second)
+			}
+		}
+	}(ns)
+	glog.Infof("Created namespace %s", ns)
+
+	// The logs and exec verbs need pods that actually run, so use a
+	// busybox loop that also produces log output.
+	names := []string{}
+	for i := 0; i < *podCount; i++ {
+		name := fmt.Sprintf("latency-%d", i)
+		names = append(names, name)
+		_, err := c.Pods(ns).Create(&api.Pod{
+			ObjectMeta: api.ObjectMeta{Name: name},
+			Spec: api.PodSpec{
+				Containers: []api.Container{
+					{
+						Name:    "main",
+						Image:   "gcr.io/google_containers/busybox",
+						Command: []string{"sh", "-c", "while true; do echo ping; sleep 1; done"},
+					},
+				},
+			},
+		})
+		if err != nil {
+			glog.Fatalf("Failed to create pod %s/%s: %v", ns, name, err)
+		}
+	}
+	glog.Infof("Created %d pods, waiting for them to run", len(names))
+
+	deadline := time.Now().Add(podRunningTimeout)
+	for _, name := range names {
+		for {
+			pod, err := c.Pods(ns).Get(name)
+			if err == nil && pod.Status.Phase == api.PodRunning {
+				break
+			}
+			if time.Now().After(deadline) {
+				glog.Fatalf("Timed out waiting for pod %s/%s to run", ns, name)
+			}
+			time.Sleep(pollInterval)
+		}
+	}
+	glog.Infof("All pods running")
+
+	verbs := []verb{
+		{"get", func(name string) error {
+			_, err := c.Pods(ns).Get(name)
+			return err
+		}},
+		{"list", func(name string) error {
+			_, err := c.Pods(ns).List(api.ListOptions{})
+			return err
+		}},
+		// What `kubectl describe pod` costs: the pod itself plus the
+		// event search about it.
+		{"describe", func(name string) error {
+			pod, err := c.Pods(ns).Get(name)
+			if err != nil {
+				return err
+			}
+			_, err = c.Events(ns).Search(pod)
+			return err
+		}},
+		{"logs", func(name string) error {
+			_, err := c.Pods(ns).GetLogs(name, &api.PodLogOptions{}).DoRaw()
+			return err
+		}},
+		// Full round trip of a trivial exec: SPDY negotiation plus
+		// running `true` in the container.
+		{"exec", func(name string) error {
+			req := c.RESTClient.Post().
+				Resource("pods").
+				Name(name).
+				Namespace(ns).
+				SubResource("exec").
+				Param("container", "main")
+			req.VersionedParams(&api.PodExecOptions{
+				Container: "main",
+				Command:   []string{"true"},
+				Stdout:    true,
+				Stderr:    true,
+			}, api.ParameterCodec)
+			exec, err := remotecommand.NewExecutor(config, "POST", req.URL())
+			if err != nil {
+				return err
+			}
+			var stdout, stderr bytes.Buffer
+			return exec.Stream(nil, &stdout, &stderr, false)
+		}},
+	}
+
+	for _, v := range verbs {
+		elapsed := []time.Duration{}
+		failures := 0
+		for i := 0; i < *samples; i++ {
+			name := names[i%len(names)]
+			start := time.Now()
+			if err := v.do(name); err != nil {
+				glog.Warningf("%s %s/%s failed: %v", v.name, ns, name, err)
+				failures++
+				continue
+			}
+			elapsed = append(elapsed, time.Since(start))
+		}
+		percentiles(v.name, elapsed)
+		if failures > 0 {
+			fmt.Printf("%-12s failures=%d\n", v.name, failures)
+		}
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i] = d[j] }
@@ -0,0 +1,245 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+This tool benchmarks the namespace lifecycle at scale. It creates many
+namespaces, each populated with a standard set of objects (a replication
+controller, a service and a secret), measures namespace creation latency,
+then deletes them all and measures how long termination and finalization
+take. Namespace deletion has to wait for the namespace controller to drain
+all contained objects, so its latency is the interesting number here.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
+	"k8s.io/kubernetes/pkg/util/intstr"
+)
+
+var (
+	namespaces   = flag.Int("namespaces", 10, "Number of namespaces to create and tear down")
+	podsPerNs    = flag.Int("pods_per_namespace", 5, "Replica count of the rc placed in each namespace")
+	inFlight     = flag.Int("max_in_flight", 5, "Maximum number of namespaces created or deleted concurrently")
+	keepOnFailed = flag.Bool("keep_on_failure", false, "Leave namespaces behind if population fails, for inspection")
+	gke          = flag.String("gke_context", "", "Target GKE cluster with context gke_{project}_{zone}_{cluster-name}")
+)
+
+const (
+	populateTimeout  = 5 * time.Minute
+	terminateTimeout = 15 * time.Minute
+	pollInterval     = 2 * time.Second
+)
+
+func makeClient() (*client.Client, error) {
+	var spec string
+	if *gke != "" {
+		spec = filepath.Join(os.Getenv("HOME"), ".config", "gcloud", "kubernetes", "kubeconfig")
+	} else {
+		spec = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+	settings, err := clientcmd.LoadFromFile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %v", err)
+	}
+	if *gke != "" {
+		settings.CurrentContext = *gke
+	}
+	config, err := clientcmd.NewDefaultClientConfig(*settings, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct config: %v", err)
+	}
+	return client.New(config)
+}
+
+// populate fills a namespace with the standard object set.
+func populate(c *client.Client, ns string) error {
+	if _, err := c.Secrets(ns).Create(&api.Secret{
+		ObjectMeta: api.ObjectMeta{Name: "standard-secret"},
+		Data:       map[string][]byte{"payload": []byte("namespace-lifecycle")},
+	}); err != nil {
+		return fmt.Errorf("failed to create secret: %v", err)
+	}
+	if _, err := c.Services(ns).Create(&api.Service{
+		ObjectMeta: api.ObjectMeta{Name: "standard-service"},
+		Spec: api.ServiceSpec{
+			Ports: []api.ServicePort{{
+				Protocol:   "TCP",
+				Port:       9376,
+				TargetPort: intstr.FromInt(9376),
+			}},
+			Selector: map[string]string{"name": "standard-rc"},
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to create service: %v", err)
+	}
+	if _, err := c.ReplicationControllers(ns).Create(&api.ReplicationController{
+		ObjectMeta: api.ObjectMeta{Name: "standard-rc"},
+		Spec: api.ReplicationControllerSpec{
+			Replicas: *podsPerNs,
+			Selector: map[string]string{"name": "standard-rc"},
+			Template: &api.PodTemplateSpec{
+				ObjectMeta: api.ObjectMeta{
+					Labels: map[string]string{"name": "standard-rc"},
+				},
+				Spec: api.PodSpec{
+					Containers: []api.Container{
+						{
+							Name:  "pause",
+							Image: "gcr.io/google_containers/pause:2.0",
+						},
+					},
+				},
+			},
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to create rc: %v", err)
+	}
+	// Wait for the pods so that deletion later has real work to do.
+	for start := time.Now(); time.Since(start) < populateTimeout; time.Sleep(pollInterval) {
+		pods, err := c.Pods(ns).List(api.ListOptions{})
+		if err != nil {
+			continue
+		}
+		running := 0
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == api.PodRunning {
+				running++
+			}
+		}
+		if running >= *podsPerNs {
+			return nil
+		}
+	}
+	return fmt.Errorf("pods did not start within %v", populateTimeout)
+}
+
+func main() {
+	flag.Parse()
+
+	glog.Infof("Starting namespace_lifecycle benchmark with namespaces=%d podsPerNamespace=%d maxInFlight=%d",
+		*namespaces, *podsPerNs, *inFlight)
+
+	c, err := makeClient()
+	if err != nil {
+		glog.Fatalf("Failed to make client: %v", err)
+	}
+
+	sem := make(chan struct{}, *inFlight)
+	var lock sync.Mutex
+	names := []string{}
+	createLatencies := []time.Duration{}
+
+	// Creation and population phase.
+	var wg sync.WaitGroup
+	for i := 0; i < *namespaces; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			t := time.Now()
+			got, err := c.Namespaces().Create(&api.Namespace{ObjectMeta: api.ObjectMeta{GenerateName: "ns-lifecycle-"}})
+			createLatency := time.Since(t)
+			if err != nil {
+				glog.Warningf("Failed to create namespace %d: %v", i, err)
+				return
+			}
+			if err := populate(c, got.Name); err != nil {
+				glog.Warningf("Failed to populate namespace %s: %v", got.Name, err)
+				if !*keepOnFailed {
+					if err := c.Namespaces().Delete(got.Name); err != nil {
+						glog.Warningf("Failed to delete namespace %s: %v", got.Name, err)
+					}
+				}
+				return
+			}
+			lock.Lock()
+			names = append(names, got.Name)
+			createLatencies = append(createLatencies, createLatency)
+			lock.Unlock()
+			glog.V(2).Infof("Namespace %s created and populated", got.Name)
+		}(i)
+	}
+	wg.Wait()
+	glog.Infof("Created and populated %d of %d namespaces", len(names), *namespaces)
+
+	// Termination phase: delete each namespace and wait until it is gone.
+	terminateLatencies := []time.Duration{}
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			t := time.Now()
+			if err := c.Namespaces().Delete(name); err != nil {
+				glog.Warningf("Failed to delete namespace %s: %v", name, err)
+				return
+			}
+			for time.Since(t) < terminateTimeout {
+				if _, err := c.Namespaces().Get(name); err != nil {
+					if errors.IsNotFound(err) {
+						lock.Lock()
+						terminateLatencies = append(terminateLatencies, time.Since(t))
+						lock.Unlock()
+						glog.V(2).Infof("Namespace %s terminated in %v", name, time.Since(t))
+						return
+					}
+				}
+				time.Sleep(pollInterval)
+			}
+			glog.Warningf("Namespace %s did not terminate within %v", name, terminateTimeout)
+		}(name)
+	}
+	wg.Wait()
+
+	report("create", createLatencies)
+	report("terminate", terminateLatencies)
+}
+
+func report(phase string, ds []time.Duration) {
+	if len(ds) == 0 {
+		fmt.Printf("%s: no samples\n", phase)
+		return
+	}
+	sort.Sort(durations(ds))
+	last := len(ds) - 1
+	p99 := len(ds) * 99 / 100
+	if p99 > last {
+		p99 = last
+	}
+	fmt.Printf("%s: namespaces=%d p50=%v p90=%v p99=%v max=%v\n",
+		phase, len(ds), ds[len(ds)*50/100], ds[len(ds)*90/100], ds[p99], ds[last])
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i] = d[j] }
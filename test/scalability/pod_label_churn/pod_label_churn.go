@@ -0,0 +1,293 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+This tool benchmarks the write path none of the create/list/delete tools
+exercise: a steady stream of label and annotation updates against a
+large standing pod population. A writer updates pods round-robin at a
+configured rate, stamping each update with the send time, while a
+configurable number of watchers observe the namespace and measure how
+long each modification takes to be delivered. At the end the tool
+reports update latency, watch delivery latency and how often watches
+were closed.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+var (
+	podCount = flag.Int("pods", 100, "Number of pods in the standing population")
+	rate     = flag.Float64("rate", 10, "Label updates per second across the whole population")
+	duration = flag.Duration("duration", 5*time.Minute, "How long to churn labels")
+	watchers = flag.Int("watchers", 1, "Number of concurrent watches observing the namespace")
+	gke      = flag.String("gke_context", "", "Target GKE cluster with context gke_{project}_{zone}_{cluster-name}")
+)
+
+// sentAnnotation carries the send timestamp of each update so watchers
+// can compute delivery latency.
+const sentAnnotation = "pod-label-churn/sent"
+
+const (
+	podCreateTimeout       = 2 * time.Minute
+	namespaceDeleteTimeout = 5 * time.Minute
+	pollInterval           = 2 * time.Second
+)
+
+func makeClient() (*client.Client, error) {
+	var spec string
+	if *gke != "" {
+		spec = filepath.Join(os.Getenv("HOME"), ".config", "gcloud", "kubernetes", "kubeconfig")
+	} else {
+		spec = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+	settings, err := clientcmd.LoadFromFile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %v", err)
+	}
+	if *gke != "" {
+		settings.CurrentContext = *gke
+	}
+	config, err := clientcmd.NewDefaultClientConfig(*settings, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct config: %v", err)
+	}
+	return client.New(config)
+}
+
+// watchLoop consumes one watch on the namespace's pods, measuring the
+// delivery latency of every modification carrying a send timestamp.
+// Closed watches are counted and reopened until stop is closed.
+func watchLoop(c *client.Client, ns string, results chan<- time.Duration, closes *int, lock *sync.Mutex, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	resourceVersion := ""
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		w, err := c.Pods(ns).Watch(api.ListOptions{ResourceVersion: resourceVersion})
+		if err != nil {
+			glog.Warningf("Failed to open watch: %v", err)
+			time.Sleep(pollInterval)
+			continue
+		}
+		for {
+			event, ok := <-w.ResultChan()
+			if !ok {
+				lock.Lock()
+				*closes++
+				lock.Unlock()
+				break
+			}
+			if event.Type != watch.Modified {
+				continue
+			}
+			pod, ok := event.Object.(*api.Pod)
+			if !ok {
+				continue
+			}
+			resourceVersion = pod.ResourceVersion
+			sent, err := time.Parse(time.RFC3339Nano, pod.Annotations[sentAnnotation])
+			if err != nil {
+				continue
+			}
+			select {
+			case results <- time.Since(sent):
+			case <-stop:
+				w.Stop()
+				return
+			}
+		}
+	}
+}
+
+func percentiles(name string, elapsed []time.Duration) {
+	if len(elapsed) == 0 {
+		fmt.Printf("%s: no samples\n", name)
+		return
+	}
+	sort.Sort(durations(elapsed))
+	fmt.Printf("%s: n=%d p50=%v p90=%v p99=%v max=%v\n",
+		name,
+		len(elapsed),
+		elapsed[len(elapsed)*50/100],
+		elapsed[len(elapsed)*90/100],
+		elapsed[min(len(elapsed)*99/100, len(elapsed)-1)],
+		elapsed[len(elapsed)-1])
+}
+
+func main() {
+	flag.Parse()
+
+	if *rate <= 0 {
+		glog.Fatalf("rate (%v) must be positive", *rate)
+	}
+
+	glog.Infof("Starting pod_label_churn with pods=%d rate=%v duration=%v watchers=%d",
+		*podCount, *rate, *duration, *watchers)
+
+	c, err := makeClient()
+	if err != nil {
+		glog.Fatalf("Failed to make client: %v", err)
+	}
+
+	got, err := c.Namespaces().Create(&api.Namespace{ObjectMeta: api.ObjectMeta{GenerateName: "pod-label-churn-"}})
+	if err != nil {
+		glog.Fatalf("Failed to create namespace: %v", err)
+	}
+	ns := got.Name
+	defer func(ns string) {
+		if err := c.Namespaces().Delete(ns); err != nil {
+			glog.Warningf("Failed to delete namespace %s: %v", ns, err)
+		} else {
+			// wait until the namespace disappears
+			for i := 0; i < int(namespaceDeleteTimeout/time.Second); i++ {
+				if _, err := c.Namespaces().Get(ns); err != nil {
+					if errors.IsNotFound(err) {
+						return
+					}
+				}
+				time.Sleep(time.Second)
+			}
+		}
+	}(ns)
+	glog.Infof("Created namespace %s", ns)
+
+	// Create the standing pod population. The pods never need to run:
+	// label churn is an apiserver and watch benchmark, so Pending pods
+	// serve just as well and the tool does not depend on cluster
+	// capacity.
+	names := []string{}
+	for i := 0; i < *podCount; i++ {
+		name := fmt.Sprintf("churn-%d", i)
+		names = append(names, name)
+		for start := time.Now(); time.Since(start) < podCreateTimeout; time.Sleep(pollInterval) {
+			_, err = c.Pods(ns).Create(&api.Pod{
+				ObjectMeta: api.ObjectMeta{
+					Name:   name,
+					Labels: map[string]string{"churn": "0"},
+				},
+				Spec: api.PodSpec{
+					Containers: []api.Container{
+						{
+							Name:  "pause",
+							Image: "gcr.io/google_containers/pause:2.0",
+						},
+					},
+				},
+			})
+			if err == nil {
+				break
+			}
+			glog.Warningf("Failed to create pod %s/%s: %v", ns, name, err)
+		}
+		if err != nil {
+			glog.Fatalf("Giving up trying to create pod %s/%s: %v", ns, name, err)
+		}
+	}
+	glog.Infof("Created %d pods", len(names))
+
+	// Start the watchers before the writer so every update is observed.
+	deliveries := make(chan time.Duration, 1000)
+	closes := 0
+	var closeLock sync.Mutex
+	stop := make(chan struct{})
+	var watchGroup sync.WaitGroup
+	for i := 0; i < *watchers; i++ {
+		watchGroup.Add(1)
+		go watchLoop(c, ns, deliveries, &closes, &closeLock, stop, &watchGroup)
+	}
+	deliveryLatency := []time.Duration{}
+	var collectGroup sync.WaitGroup
+	collectGroup.Add(1)
+	go func() {
+		defer collectGroup.Done()
+		for d := range deliveries {
+			deliveryLatency = append(deliveryLatency, d)
+		}
+	}()
+
+	// Drive updates round-robin at the configured rate.
+	updateLatency := []time.Duration{}
+	failures := 0
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+	defer ticker.Stop()
+	deadline := time.Now().Add(*duration)
+	for seq := 0; time.Now().Before(deadline); seq++ {
+		<-ticker.C
+		name := names[seq%len(names)]
+		pod, err := c.Pods(ns).Get(name)
+		if err != nil {
+			glog.Warningf("Failed to get pod %s/%s: %v", ns, name, err)
+			failures++
+			continue
+		}
+		pod.Labels["churn"] = strconv.Itoa(seq)
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations["pod-label-churn/seq"] = strconv.Itoa(seq)
+		pod.Annotations[sentAnnotation] = time.Now().Format(time.RFC3339Nano)
+		start := time.Now()
+		if _, err := c.Pods(ns).Update(pod); err != nil {
+			glog.Warningf("Failed to update pod %s/%s: %v", ns, name, err)
+			failures++
+			continue
+		}
+		updateLatency = append(updateLatency, time.Since(start))
+	}
+
+	close(stop)
+	watchGroup.Wait()
+	close(deliveries)
+	collectGroup.Wait()
+
+	percentiles("update latency", updateLatency)
+	percentiles("watch delivery latency", deliveryLatency)
+	fmt.Printf("updates=%d failures=%d watchers=%d watch closes=%d\n",
+		len(updateLatency), failures, *watchers, closes)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i] = d[j] }
@@ -0,0 +1,253 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+This tool measures pod creation-to-running latency broken down per node.
+It places a configurable number of pause pods on each node, watches them
+until they are running and then reports latency percentiles for each node
+as well as for the cluster as a whole. Nodes whose median latency exceeds
+the cluster median by a configurable factor are flagged as slow, since a
+few slow nodes can skew the cluster-wide percentiles reported by density
+tests.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
+	"k8s.io/kubernetes/pkg/fields"
+)
+
+var (
+	podsPerNode = flag.Int("pods_per_node", 10, "Number of pause pods to place on each node")
+	nodeFilter  = flag.String("node", "", "Only measure the node with this name rather than every node")
+	slowFactor  = flag.Float64("slow_factor", 1.5, "Flag nodes whose median latency exceeds the cluster median by this factor")
+	gke         = flag.String("gke_context", "", "Target GKE cluster with context gke_{project}_{zone}_{cluster-name}")
+)
+
+const (
+	nodeListTimeout        = 2 * time.Minute
+	podCreateTimeout       = 2 * time.Minute
+	podStartTimeout        = 10 * time.Minute
+	namespaceDeleteTimeout = 5 * time.Minute
+	pollInterval           = 2 * time.Second
+)
+
+// latencies maps a node name to the observed creation-to-running
+// latencies of the pods scheduled onto it.
+type latencies map[string][]time.Duration
+
+func percentile(ds []time.Duration, p int) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	i := (len(ds) * p) / 100
+	if i >= len(ds) {
+		i = len(ds) - 1
+	}
+	return ds[i]
+}
+
+func makeClient() (*client.Client, error) {
+	var spec string
+	if *gke != "" {
+		spec = filepath.Join(os.Getenv("HOME"), ".config", "gcloud", "kubernetes", "kubeconfig")
+	} else {
+		spec = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+	settings, err := clientcmd.LoadFromFile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %v", err)
+	}
+	if *gke != "" {
+		settings.CurrentContext = *gke
+	}
+	config, err := clientcmd.NewDefaultClientConfig(*settings, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct config: %v", err)
+	}
+	return client.New(config)
+}
+
+func main() {
+	flag.Parse()
+
+	glog.Infof("Starting pod_start_latency with podsPerNode=%d", *podsPerNode)
+
+	c, err := makeClient()
+	if err != nil {
+		glog.Fatalf("Failed to make client: %v", err)
+	}
+
+	var nodes *api.NodeList
+	for start := time.Now(); time.Since(start) < nodeListTimeout; time.Sleep(pollInterval) {
+		nodes, err = c.Nodes().List(api.ListOptions{})
+		if err == nil {
+			break
+		}
+		glog.Warningf("Failed to list nodes: %v", err)
+	}
+	if err != nil {
+		glog.Fatalf("Giving up trying to list nodes: %v", err)
+	}
+
+	nodeNames := []string{}
+	for _, node := range nodes.Items {
+		if *nodeFilter != "" && node.Name != *nodeFilter {
+			continue
+		}
+		nodeNames = append(nodeNames, node.Name)
+	}
+	if len(nodeNames) == 0 {
+		glog.Fatalf("Failed to find any nodes to measure.")
+	}
+	glog.Infof("Measuring pod start latency on %d nodes", len(nodeNames))
+
+	// Create the namespace.
+	got, err := c.Namespaces().Create(&api.Namespace{ObjectMeta: api.ObjectMeta{GenerateName: "pod-start-latency-"}})
+	if err != nil {
+		glog.Fatalf("Failed to create namespace: %v", err)
+	}
+	ns := got.Name
+	defer func(ns string) {
+		if err := c.Namespaces().Delete(ns); err != nil {
+			glog.Warningf("Failed to delete namespace %s: %v", ns, err)
+		} else {
+			// wait until the namespace disappears
+			for i := 0; i < int(namespaceDeleteTimeout/time.Second); i++ {
+				if _, err := c.Namespaces().Get(ns); err != nil {
+					if errors.IsNotFound(err) {
+						return
+					}
+				}
+				time.Sleep(time.Second)
+			}
+		}
+	}(ns)
+	glog.Infof("Created namespace %s", ns)
+
+	// Place pods on each node under measurement. Binding the pod to a
+	// specific node with NodeName takes the scheduler out of the picture so
+	// the latency we measure is dominated by the kubelet and the container
+	// runtime on that node.
+	created := time.Now()
+	podNames := []string{}
+	for i, name := range nodeNames {
+		for j := 0; j < *podsPerNode; j++ {
+			podName := fmt.Sprintf("pod-start-latency-%d-%d", i, j)
+			podNames = append(podNames, podName)
+			for start := time.Now(); time.Since(start) < podCreateTimeout; time.Sleep(pollInterval) {
+				_, err = c.Pods(ns).Create(&api.Pod{
+					ObjectMeta: api.ObjectMeta{
+						Name:   podName,
+						Labels: map[string]string{"name": "pod-start-latency"},
+					},
+					Spec: api.PodSpec{
+						Containers: []api.Container{
+							{
+								Name:  "pause",
+								Image: "gcr.io/google_containers/pause:2.0",
+							},
+						},
+						NodeName: name,
+					},
+				})
+				if err == nil {
+					break
+				}
+				glog.Warningf("Failed to create pod %s/%s: %v", ns, podName, err)
+			}
+			if err != nil {
+				glog.Fatalf("Giving up trying to create pod %s/%s: %v", ns, podName, err)
+			}
+		}
+	}
+	glog.Infof("Created %d pods in namespace %s", len(podNames), ns)
+
+	// Poll until every pod is running (or we time out) and record
+	// per-node latencies as pods arrive.
+	perNode := latencies{}
+	running := map[string]bool{}
+	for start := time.Now(); time.Since(start) < podStartTimeout; time.Sleep(pollInterval) {
+		pods, err := c.Pods(ns).List(api.ListOptions{FieldSelector: fields.Everything()})
+		if err != nil {
+			glog.Warningf("Failed to list pods in namespace %s: %v", ns, err)
+			continue
+		}
+		for _, pod := range pods.Items {
+			if running[pod.Name] || pod.Status.Phase != api.PodRunning {
+				continue
+			}
+			running[pod.Name] = true
+			latency := time.Since(created)
+			if pod.Status.StartTime != nil {
+				latency = pod.Status.StartTime.Sub(pod.CreationTimestamp.Time)
+			}
+			perNode[pod.Spec.NodeName] = append(perNode[pod.Spec.NodeName], latency)
+		}
+		if len(running) == len(podNames) {
+			break
+		}
+		glog.V(4).Infof("%d of %d pods running", len(running), len(podNames))
+	}
+	if len(running) < len(podNames) {
+		glog.Warningf("Only %d of %d pods reached running before timeout", len(running), len(podNames))
+	}
+
+	// Report cluster-wide and per-node percentiles.
+	all := []time.Duration{}
+	for _, ds := range perNode {
+		all = append(all, ds...)
+	}
+	sort.Sort(durations(all))
+	clusterMedian := percentile(all, 50)
+	fmt.Printf("cluster: pods=%d p50=%v p90=%v p99=%v\n",
+		len(all), clusterMedian, percentile(all, 90), percentile(all, 99))
+
+	sortedNodes := []string{}
+	for name := range perNode {
+		sortedNodes = append(sortedNodes, name)
+	}
+	sort.Strings(sortedNodes)
+	for _, name := range sortedNodes {
+		ds := perNode[name]
+		sort.Sort(durations(ds))
+		median := percentile(ds, 50)
+		slow := ""
+		if clusterMedian > 0 && float64(median) > *slowFactor*float64(clusterMedian) {
+			slow = " SLOW"
+		}
+		fmt.Printf("node %s: pods=%d p50=%v p90=%v p99=%v%s\n",
+			name, len(ds), median, percentile(ds, 90), percentile(ds, 99), slow)
+	}
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i] = d[j] }
@@ -0,0 +1,266 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+This tool benchmarks the replication controller manager by repeatedly
+resizing replication controllers between two configurable sizes. For each
+resize it measures the time until the controller converges: every replica
+Running on the way up, every surplus pod deleted on the way down. Several
+controllers can be resized concurrently to observe how the RC manager
+behaves under concurrent resizes.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+var (
+	controllers = flag.Int("controllers", 1, "Number of replication controllers to resize concurrently")
+	lowSize     = flag.Int("low", 1, "Replica count to scale down to")
+	highSize    = flag.Int("high", 10, "Replica count to scale up to")
+	iterations  = flag.Int("iterations", 10, "Number of up/down resize cycles per controller")
+	gke         = flag.String("gke_context", "", "Target GKE cluster with context gke_{project}_{zone}_{cluster-name}")
+)
+
+const (
+	convergeTimeout        = 10 * time.Minute
+	rcCreateTimeout        = 2 * time.Minute
+	namespaceDeleteTimeout = 5 * time.Minute
+	pollInterval           = 2 * time.Second
+)
+
+func makeClient() (*client.Client, error) {
+	var spec string
+	if *gke != "" {
+		spec = filepath.Join(os.Getenv("HOME"), ".config", "gcloud", "kubernetes", "kubeconfig")
+	} else {
+		spec = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+	settings, err := clientcmd.LoadFromFile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %v", err)
+	}
+	if *gke != "" {
+		settings.CurrentContext = *gke
+	}
+	config, err := clientcmd.NewDefaultClientConfig(*settings, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct config: %v", err)
+	}
+	return client.New(config)
+}
+
+// waitForConverge polls until exactly size pods selected by selector are
+// Running and returns how long convergence took.
+func waitForConverge(c *client.Client, ns string, selector labels.Selector, size int) (time.Duration, error) {
+	start := time.Now()
+	for time.Since(start) < convergeTimeout {
+		pods, err := c.Pods(ns).List(api.ListOptions{LabelSelector: selector})
+		if err != nil {
+			glog.Warningf("Failed to list pods in namespace %s: %v", ns, err)
+			time.Sleep(pollInterval)
+			continue
+		}
+		running := 0
+		for _, pod := range pods.Items {
+			if pod.DeletionTimestamp != nil {
+				continue
+			}
+			if pod.Status.Phase == api.PodRunning {
+				running++
+			}
+		}
+		if running == size && len(pods.Items) >= size {
+			// All desired replicas running; make sure no surplus pods
+			// remain when scaling down.
+			surplus := 0
+			for _, pod := range pods.Items {
+				if pod.DeletionTimestamp == nil {
+					surplus++
+				}
+			}
+			if surplus == size {
+				return time.Since(start), nil
+			}
+		}
+		time.Sleep(pollInterval)
+	}
+	return time.Since(start), fmt.Errorf("did not converge to %d replicas within %v", size, convergeTimeout)
+}
+
+// resizeLoop drives one controller through the configured number of
+// resize cycles and sends each convergence time to results.
+func resizeLoop(c *client.Client, ns, name string, results chan<- time.Duration, wg *sync.WaitGroup) {
+	defer wg.Done()
+	selector := labels.SelectorFromSet(labels.Set{"name": name})
+	for i := 0; i < *iterations; i++ {
+		for _, size := range []int{*highSize, *lowSize} {
+			rc, err := c.ReplicationControllers(ns).Get(name)
+			if err != nil {
+				glog.Warningf("Failed to get rc %s/%s: %v", ns, name, err)
+				continue
+			}
+			rc.Spec.Replicas = size
+			if _, err := c.ReplicationControllers(ns).Update(rc); err != nil {
+				glog.Warningf("Failed to resize rc %s/%s to %d: %v", ns, name, size, err)
+				continue
+			}
+			elapsed, err := waitForConverge(c, ns, selector, size)
+			if err != nil {
+				glog.Warningf("Resize of rc %s/%s to %d: %v", ns, name, size, err)
+				continue
+			}
+			glog.Infof("rc %s/%s converged to %d replicas in %v", ns, name, size, elapsed)
+			results <- elapsed
+		}
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	if *lowSize >= *highSize {
+		glog.Fatalf("low (%d) must be less than high (%d)", *lowSize, *highSize)
+	}
+
+	glog.Infof("Starting rc_resize benchmark with controllers=%d low=%d high=%d iterations=%d",
+		*controllers, *lowSize, *highSize, *iterations)
+
+	c, err := makeClient()
+	if err != nil {
+		glog.Fatalf("Failed to make client: %v", err)
+	}
+
+	// Create the namespace.
+	got, err := c.Namespaces().Create(&api.Namespace{ObjectMeta: api.ObjectMeta{GenerateName: "rc-resize-"}})
+	if err != nil {
+		glog.Fatalf("Failed to create namespace: %v", err)
+	}
+	ns := got.Name
+	defer func(ns string) {
+		if err := c.Namespaces().Delete(ns); err != nil {
+			glog.Warningf("Failed to delete namespace %s: %v", ns, err)
+		} else {
+			// wait until the namespace disappears
+			for i := 0; i < int(namespaceDeleteTimeout/time.Second); i++ {
+				if _, err := c.Namespaces().Get(ns); err != nil {
+					if errors.IsNotFound(err) {
+						return
+					}
+				}
+				time.Sleep(time.Second)
+			}
+		}
+	}(ns)
+	glog.Infof("Created namespace %s", ns)
+
+	// Create the replication controllers at the low size.
+	names := []string{}
+	for i := 0; i < *controllers; i++ {
+		name := fmt.Sprintf("rc-resize-%d", i)
+		names = append(names, name)
+		for start := time.Now(); time.Since(start) < rcCreateTimeout; time.Sleep(pollInterval) {
+			_, err = c.ReplicationControllers(ns).Create(&api.ReplicationController{
+				ObjectMeta: api.ObjectMeta{Name: name},
+				Spec: api.ReplicationControllerSpec{
+					Replicas: *lowSize,
+					Selector: map[string]string{"name": name},
+					Template: &api.PodTemplateSpec{
+						ObjectMeta: api.ObjectMeta{
+							Labels: map[string]string{"name": name},
+						},
+						Spec: api.PodSpec{
+							Containers: []api.Container{
+								{
+									Name:  "pause",
+									Image: "gcr.io/google_containers/pause:2.0",
+								},
+							},
+						},
+					},
+				},
+			})
+			if err == nil {
+				break
+			}
+			glog.Warningf("Failed to create rc %s/%s: %v", ns, name, err)
+		}
+		if err != nil {
+			glog.Fatalf("Giving up trying to create rc %s/%s: %v", ns, name, err)
+		}
+	}
+
+	// Wait for the initial replicas before starting the clock.
+	for _, name := range names {
+		selector := labels.SelectorFromSet(labels.Set{"name": name})
+		if _, err := waitForConverge(c, ns, selector, *lowSize); err != nil {
+			glog.Fatalf("rc %s/%s never reached initial size: %v", ns, name, err)
+		}
+	}
+	glog.Infof("All %d controllers at initial size %d", len(names), *lowSize)
+
+	results := make(chan time.Duration, *controllers**iterations*2)
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go resizeLoop(c, ns, name, results, &wg)
+	}
+	wg.Wait()
+	close(results)
+
+	elapsed := []time.Duration{}
+	for d := range results {
+		elapsed = append(elapsed, d)
+	}
+	if len(elapsed) == 0 {
+		glog.Fatalf("No resizes converged.")
+	}
+	sort.Sort(durations(elapsed))
+	fmt.Printf("resizes=%d p50=%v p90=%v p99=%v max=%v\n",
+		len(elapsed),
+		elapsed[len(elapsed)*50/100],
+		elapsed[len(elapsed)*90/100],
+		elapsed[min(len(elapsed)*99/100, len(elapsed)-1)],
+		elapsed[len(elapsed)-1])
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i] = d[j] }
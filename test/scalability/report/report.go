@@ -0,0 +1,328 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+report combines the results of a batch of scalability tool runs into a
+single document. Each tool run writes one JSON file into a results
+directory:
+
+    {
+      "tool": "createpods",
+      "timestamp": "2016-01-20T10:00:00Z",
+      "params": {"pods": "3000", "rate": "50"},
+      "metrics": [
+        {"name": "create", "unit": "ms", "count": 3000,
+         "p50": 42, "p90": 87, "p99": 240, "max": 1100}
+      ]
+    }
+
+and report renders every file in the directory as Markdown or HTML with
+a table and a latency chart per run. With --baseline pointing at the
+results directory of an earlier (known good) batch, each metric is also
+compared against the same tool and metric in the baseline, and changes
+beyond --regression_threshold are flagged, so a scheduled job can diff
+today's cluster against the last release with:
+
+    report --results=today/ --baseline=v1.1/ --format=html > report.html
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+var (
+	resultsDir          = flag.String("results", "", "Directory of JSON result files to report on")
+	baselineDir         = flag.String("baseline", "", "Directory of JSON result files from a baseline batch to compare against")
+	format              = flag.String("format", "markdown", "Output format: markdown or html")
+	regressionThreshold = flag.Float64("regression_threshold", 0.2, "Fraction by which a latency percentile may exceed the baseline before it is flagged, e.g. 0.2 for 20%")
+)
+
+// chartWidth is how many characters (Markdown) or pixels out of 400
+// (HTML) the longest bar of a chart occupies.
+const chartWidth = 40
+
+// metric is one latency distribution measured by a run.
+type metric struct {
+	Name  string  `json:"name"`
+	Unit  string  `json:"unit,omitempty"`
+	Count int     `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+}
+
+// runResult is the JSON document one tool run produces.
+type runResult struct {
+	Tool      string            `json:"tool"`
+	Timestamp time.Time         `json:"timestamp"`
+	Params    map[string]string `json:"params,omitempty"`
+	Metrics   []metric          `json:"metrics"`
+}
+
+// regression describes a metric that moved against the baseline by more
+// than the threshold. Improvements are reported too, with a negative
+// delta, since an unexplained big improvement often means the test
+// measured less than it used to.
+type regression struct {
+	tool       string
+	metric     string
+	percentile string
+	current    float64
+	baseline   float64
+	delta      float64
+}
+
+// loadResults parses every .json file in a directory. Files that do not
+// parse are skipped with a warning so one truncated result does not
+// hide the rest of the batch.
+func loadResults(dir string) ([]runResult, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	results := []runResult{}
+	for _, path := range paths {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		result := runResult{}
+		if err := json.Unmarshal(raw, &result); err != nil {
+			glog.Warningf("Skipping %s: %v", path, err)
+			continue
+		}
+		if result.Tool == "" {
+			result.Tool = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		results = append(results, result)
+	}
+	sort.Sort(byTool(results))
+	return results, nil
+}
+
+type byTool []runResult
+
+func (b byTool) Len() int      { return len(b) }
+func (b byTool) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byTool) Less(i, j int) bool {
+	if b[i].Tool != b[j].Tool {
+		return b[i].Tool < b[j].Tool
+	}
+	return b[i].Timestamp.Before(b[j].Timestamp)
+}
+
+// paramString flattens a run's parameters for display.
+func paramString(params map[string]string) string {
+	keys := []string{}
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := []string{}
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%s", key, params[key]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// findRegressions compares each metric of the current batch with the
+// same tool and metric in the baseline batch.
+func findRegressions(current, baseline []runResult) []regression {
+	type key struct{ tool, metric string }
+	base := map[key]metric{}
+	for _, result := range baseline {
+		for _, m := range result.Metrics {
+			base[key{result.Tool, m.Name}] = m
+		}
+	}
+	regressions := []regression{}
+	for _, result := range current {
+		for _, m := range result.Metrics {
+			b, ok := base[key{result.Tool, m.Name}]
+			if !ok {
+				continue
+			}
+			percentiles := []struct {
+				name              string
+				current, baseline float64
+			}{
+				{"p50", m.P50, b.P50},
+				{"p99", m.P99, b.P99},
+			}
+			for _, p := range percentiles {
+				if p.baseline == 0 {
+					continue
+				}
+				delta := (p.current - p.baseline) / p.baseline
+				if delta > *regressionThreshold || delta < -*regressionThreshold {
+					regressions = append(regressions, regression{
+						tool:       result.Tool,
+						metric:     m.Name,
+						percentile: p.name,
+						current:    p.current,
+						baseline:   p.baseline,
+						delta:      delta,
+					})
+				}
+			}
+		}
+	}
+	return regressions
+}
+
+// chartMax returns the largest p99 of a run's metrics, which scales the
+// chart bars.
+func chartMax(metrics []metric) float64 {
+	max := 0.0
+	for _, m := range metrics {
+		if m.P99 > max {
+			max = m.P99
+		}
+	}
+	return max
+}
+
+func writeMarkdown(w *os.File, results []runResult, regressions []regression, haveBaseline bool) {
+	fmt.Fprintf(w, "# Scalability report\n")
+	if haveBaseline {
+		if len(regressions) == 0 {
+			fmt.Fprintf(w, "\nNo metric moved more than %.0f%% against the baseline.\n", *regressionThreshold*100)
+		} else {
+			fmt.Fprintf(w, "\n## Changes against baseline\n\n")
+			fmt.Fprintf(w, "| Tool | Metric | Percentile | Baseline | Current | Change |\n")
+			fmt.Fprintf(w, "|------|--------|------------|----------|---------|--------|\n")
+			for _, r := range regressions {
+				fmt.Fprintf(w, "| %s | %s | %s | %.1f | %.1f | %+.0f%% |\n",
+					r.tool, r.metric, r.percentile, r.baseline, r.current, r.delta*100)
+			}
+		}
+	}
+	for _, result := range results {
+		fmt.Fprintf(w, "\n## %s\n\n", result.Tool)
+		if len(result.Params) > 0 {
+			fmt.Fprintf(w, "Run at %s with %s\n\n", result.Timestamp.Format(time.RFC3339), paramString(result.Params))
+		}
+		fmt.Fprintf(w, "| Metric | n | p50 | p90 | p99 | max |\n")
+		fmt.Fprintf(w, "|--------|---|-----|-----|-----|-----|\n")
+		for _, m := range result.Metrics {
+			unit := m.Unit
+			fmt.Fprintf(w, "| %s | %d | %.1f%s | %.1f%s | %.1f%s | %.1f%s |\n",
+				m.Name, m.Count, m.P50, unit, m.P90, unit, m.P99, unit, m.Max, unit)
+		}
+		// A p99 bar chart; code blocks render in a fixed-width font so
+		// the bars line up.
+		max := chartMax(result.Metrics)
+		if max > 0 {
+			fmt.Fprintf(w, "\n```\n")
+			for _, m := range result.Metrics {
+				bar := int(m.P99 / max * chartWidth)
+				fmt.Fprintf(w, "%-20s %s %.1f%s\n", m.Name, strings.Repeat("#", bar), m.P99, m.Unit)
+			}
+			fmt.Fprintf(w, "```\n")
+		}
+	}
+}
+
+func writeHTML(w *os.File, results []runResult, regressions []regression, haveBaseline bool) {
+	fmt.Fprintf(w, "<h1>Scalability report</h1>\n")
+	if haveBaseline {
+		if len(regressions) == 0 {
+			fmt.Fprintf(w, "<p>No metric moved more than %.0f%% against the baseline.</p>\n", *regressionThreshold*100)
+		} else {
+			fmt.Fprintf(w, "<h2>Changes against baseline</h2>\n<table border=1>\n")
+			fmt.Fprintf(w, "<tr><th>Tool</th><th>Metric</th><th>Percentile</th><th>Baseline</th><th>Current</th><th>Change</th></tr>\n")
+			for _, r := range regressions {
+				color := "red"
+				if r.delta < 0 {
+					color = "green"
+				}
+				fmt.Fprintf(w, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%.1f</td><td>%.1f</td><td><font color=%q>%+.0f%%</font></td></tr>\n",
+					html.EscapeString(r.tool), html.EscapeString(r.metric), r.percentile, r.baseline, r.current, color, r.delta*100)
+			}
+			fmt.Fprintf(w, "</table>\n")
+		}
+	}
+	for _, result := range results {
+		fmt.Fprintf(w, "<h2>%s</h2>\n", html.EscapeString(result.Tool))
+		if len(result.Params) > 0 {
+			fmt.Fprintf(w, "<p>Run at %s with %s</p>\n",
+				result.Timestamp.Format(time.RFC3339), html.EscapeString(paramString(result.Params)))
+		}
+		fmt.Fprintf(w, "<table border=1>\n<tr><th>Metric</th><th>n</th><th>p50</th><th>p90</th><th>p99</th><th>max</th></tr>\n")
+		for _, m := range result.Metrics {
+			unit := html.EscapeString(m.Unit)
+			fmt.Fprintf(w, "<tr><td>%s</td><td>%d</td><td>%.1f%s</td><td>%.1f%s</td><td>%.1f%s</td><td>%.1f%s</td></tr>\n",
+				html.EscapeString(m.Name), m.Count, m.P50, unit, m.P90, unit, m.P99, unit, m.Max, unit)
+		}
+		fmt.Fprintf(w, "</table>\n")
+		max := chartMax(result.Metrics)
+		if max > 0 {
+			for _, m := range result.Metrics {
+				width := int(m.P99 / max * 10 * chartWidth)
+				fmt.Fprintf(w, "<div><div style=\"display:inline-block;width:150px\">%s</div>"+
+					"<div style=\"display:inline-block;background:steelblue;height:1em;width:%dpx\"></div> %.1f%s</div>\n",
+					html.EscapeString(m.Name), width, m.P99, html.EscapeString(m.Unit))
+			}
+		}
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	if *resultsDir == "" {
+		glog.Fatalf("--results is required")
+	}
+	results, err := loadResults(*resultsDir)
+	if err != nil {
+		glog.Fatalf("Failed to load results from %s: %v", *resultsDir, err)
+	}
+	if len(results) == 0 {
+		glog.Fatalf("No result files found in %s", *resultsDir)
+	}
+
+	regressions := []regression{}
+	if *baselineDir != "" {
+		baseline, err := loadResults(*baselineDir)
+		if err != nil {
+			glog.Fatalf("Failed to load baseline from %s: %v", *baselineDir, err)
+		}
+		regressions = findRegressions(results, baseline)
+	}
+
+	switch *format {
+	case "markdown":
+		writeMarkdown(os.Stdout, results, regressions, *baselineDir != "")
+	case "html":
+		writeHTML(os.Stdout, results, regressions, *baselineDir != "")
+	default:
+		glog.Fatalf("Unknown format %q (expected markdown or html)", *format)
+	}
+}
@@ -0,0 +1,350 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+This tool characterizes how apiserver watch delivery behaves as the
+number of concurrent watchers grows. A writer churns annotations on a
+small pod population at a fixed rate while the tool ramps the number of
+open watches in steps, holding each step long enough to collect delivery
+latency samples. For every step it reports the delivery latency
+percentiles observed at that watcher count together with how many
+watches were closed by the server, so the fan-out cost of additional
+watchers is visible directly.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+var (
+	podCount     = flag.Int("pods", 50, "Number of pods in the churned population")
+	rate         = flag.Float64("rate", 20, "Object updates per second driven by the writer")
+	maxWatchers  = flag.Int("watchers", 100, "Watcher count to ramp up to")
+	watcherStep  = flag.Int("watcher-step", 10, "Watchers added at each step of the ramp")
+	stepInterval = flag.Duration("step-interval", 30*time.Second, "How long to hold each watcher count before adding more")
+	gke          = flag.String("gke_context", "", "Target GKE cluster with context gke_{project}_{zone}_{cluster-name}")
+)
+
+// sentAnnotation carries the send timestamp of each update so watchers
+// can compute delivery latency.
+const sentAnnotation = "watch-fanout/sent"
+
+const (
+	podCreateTimeout       = 2 * time.Minute
+	namespaceDeleteTimeout = 5 * time.Minute
+	pollInterval           = 2 * time.Second
+)
+
+func makeClient() (*client.Client, error) {
+	var spec string
+	if *gke != "" {
+		spec = filepath.Join(os.Getenv("HOME"), ".config", "gcloud", "kubernetes", "kubeconfig")
+	} else {
+		spec = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+	settings, err := clientcmd.LoadFromFile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error loading configuration: %v", err)
+	}
+	if *gke != "" {
+		settings.CurrentContext = *gke
+	}
+	config, err := clientcmd.NewDefaultClientConfig(*settings, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct config: %v", err)
+	}
+	return client.New(config)
+}
+
+// sample ties a delivery latency to the watcher count in effect when it
+// was observed, so the collector can bucket results per ramp step.
+type sample struct {
+	level   int
+	elapsed time.Duration
+}
+
+// fanoutStats accumulates per-step results. All fields are guarded by
+// the mutex since watchers and the collector update them concurrently.
+type fanoutStats struct {
+	sync.Mutex
+	// level is the watcher count currently in effect.
+	level int
+	// latency holds the delivery latency samples seen at each level.
+	latency map[int][]time.Duration
+	// closes counts watches closed by the server at each level.
+	closes map[int]int
+}
+
+func (s *fanoutStats) currentLevel() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.level
+}
+
+func (s *fanoutStats) setLevel(level int) {
+	s.Lock()
+	defer s.Unlock()
+	s.level = level
+}
+
+func (s *fanoutStats) noteClose() {
+	s.Lock()
+	defer s.Unlock()
+	s.closes[s.level]++
+}
+
+// watchLoop consumes one watch on the namespace's pods, reporting the
+// delivery latency of every modification carrying a send timestamp.
+// Closed watches are counted against the current ramp level and
+// reopened until stop is closed.
+func watchLoop(c *client.Client, ns string, results chan<- sample, stats *fanoutStats, stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	resourceVersion := ""
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		w, err := c.Pods(ns).Watch(api.ListOptions{ResourceVersion: resourceVersion})
+		if err != nil {
+			glog.Warningf("Failed to open watch: %v", err)
+			time.Sleep(pollInterval)
+			continue
+		}
+		for {
+			event, ok := <-w.ResultChan()
+			if !ok {
+				stats.noteClose()
+				break
+			}
+			if event.Type != watch.Modified {
+				continue
+			}
+			pod, ok := event.Object.(*api.Pod)
+			if !ok {
+				continue
+			}
+			resourceVersion = pod.ResourceVersion
+			sent, err := time.Parse(time.RFC3339Nano, pod.Annotations[sentAnnotation])
+			if err != nil {
+				continue
+			}
+			select {
+			case results <- sample{level: stats.currentLevel(), elapsed: time.Since(sent)}:
+			case <-stop:
+				w.Stop()
+				return
+			}
+		}
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	if *rate <= 0 {
+		glog.Fatalf("rate (%v) must be positive", *rate)
+	}
+	if *watcherStep <= 0 {
+		glog.Fatalf("watcher-step (%d) must be positive", *watcherStep)
+	}
+
+	glog.Infof("Starting watch_fanout with pods=%d rate=%v watchers=%d step=%d interval=%v",
+		*podCount, *rate, *maxWatchers, *watcherStep, *stepInterval)
+
+	c, err := makeClient()
+	if err != nil {
+		glog.Fatalf("Failed to make client: %v", err)
+	}
+
+	got, err := c.Namespaces().Create(&api.Namespace{ObjectMeta: api.ObjectMeta{GenerateName: "watch-fanout-"}})
+	if err != nil {
+		glog.Fatalf("Failed to create namespace: %v", err)
+	}
+	ns := got.Name
+	defer func(ns string) {
+		if err := c.Namespaces().Delete(ns); err != nil {
+			glog.Warningf("Failed to delete namespace %s: %v", ns, err)
+		} else {
+			// wait until the namespace disappears
+			for i := 0; i < int(namespaceDeleteTimeout/time.Second); i++ {
+				if _, err := c.Namespaces().Get(ns); err != nil {
+					if errors.IsNotFound(err) {
+						return
+					}
+				}
+				time.Sleep(time.Second)
+			}
+		}
+	}(ns)
+	glog.Infof("Created namespace %s", ns)
+
+	// Create the churned pod population. The pods never need to run:
+	// watch fan-out is an apiserver benchmark, so Pending pods serve
+	// just as well and the tool does not depend on cluster capacity.
+	names := []string{}
+	for i := 0; i < *podCount; i++ {
+		name := fmt.Sprintf("fanout-%d", i)
+		names = append(names, name)
+		for start := time.Now(); time.Since(start) < podCreateTimeout; time.Sleep(pollInterval) {
+			_, err = c.Pods(ns).Create(&api.Pod{
+				ObjectMeta: api.ObjectMeta{
+					Name:   name,
+					Labels: map[string]string{"fanout": "true"},
+				},
+				Spec: api.PodSpec{
+					Containers: []api.Container{
+						{
+							Name:  "pause",
+							Image: "gcr.io/google_containers/pause:2.0",
+						},
+					},
+				},
+			})
+			if err == nil {
+				break
+			}
+			glog.Warningf("Failed to create pod %s/%s: %v", ns, name, err)
+		}
+		if err != nil {
+			glog.Fatalf("Giving up trying to create pod %s/%s: %v", ns, name, err)
+		}
+	}
+	glog.Infof("Created %d pods", len(names))
+
+	stats := &fanoutStats{
+		latency: map[int][]time.Duration{},
+		closes:  map[int]int{},
+	}
+	deliveries := make(chan sample, 1000)
+	stop := make(chan struct{})
+	var watchGroup sync.WaitGroup
+	var collectGroup sync.WaitGroup
+	collectGroup.Add(1)
+	go func() {
+		defer collectGroup.Done()
+		for s := range deliveries {
+			stats.Lock()
+			stats.latency[s.level] = append(stats.latency[s.level], s.elapsed)
+			stats.Unlock()
+		}
+	}()
+
+	// Drive updates round-robin at the configured rate while ramping
+	// the watcher count. Each step adds watchers, then holds for the
+	// step interval so the collector accumulates samples at that level.
+	writerStop := make(chan struct{})
+	var writerGroup sync.WaitGroup
+	writerGroup.Add(1)
+	failures := 0
+	go func() {
+		defer writerGroup.Done()
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+		defer ticker.Stop()
+		for seq := 0; ; seq++ {
+			select {
+			case <-writerStop:
+				return
+			case <-ticker.C:
+			}
+			name := names[seq%len(names)]
+			pod, err := c.Pods(ns).Get(name)
+			if err != nil {
+				glog.Warningf("Failed to get pod %s/%s: %v", ns, name, err)
+				failures++
+				continue
+			}
+			if pod.Annotations == nil {
+				pod.Annotations = map[string]string{}
+			}
+			pod.Annotations["watch-fanout/seq"] = strconv.Itoa(seq)
+			pod.Annotations[sentAnnotation] = time.Now().Format(time.RFC3339Nano)
+			if _, err := c.Pods(ns).Update(pod); err != nil {
+				glog.Warningf("Failed to update pod %s/%s: %v", ns, name, err)
+				failures++
+			}
+		}
+	}()
+
+	levels := []int{}
+	running := 0
+	for running < *maxWatchers {
+		add := min(*watcherStep, *maxWatchers-running)
+		for i := 0; i < add; i++ {
+			watchGroup.Add(1)
+			go watchLoop(c, ns, deliveries, stats, stop, &watchGroup)
+		}
+		running += add
+		stats.setLevel(running)
+		levels = append(levels, running)
+		glog.Infof("Holding at %d watchers for %v", running, *stepInterval)
+		time.Sleep(*stepInterval)
+	}
+
+	close(writerStop)
+	writerGroup.Wait()
+	close(stop)
+	watchGroup.Wait()
+	close(deliveries)
+	collectGroup.Wait()
+
+	fmt.Printf("Delivery latency by watcher count (update failures=%d):\n", failures)
+	for _, level := range levels {
+		elapsed := stats.latency[level]
+		if len(elapsed) == 0 {
+			fmt.Printf("%4d watchers: no samples, closes=%d\n", level, stats.closes[level])
+			continue
+		}
+		sort.Sort(durations(elapsed))
+		fmt.Printf("%4d watchers: n=%d p50=%v p90=%v p99=%v max=%v closes=%d\n",
+			level,
+			len(elapsed),
+			elapsed[len(elapsed)*50/100],
+			elapsed[len(elapsed)*90/100],
+			elapsed[min(len(elapsed)*99/100, len(elapsed)-1)],
+			elapsed[len(elapsed)-1],
+			stats.closes[level])
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i] = d[j] }
@@ -0,0 +1,267 @@
+/*
+Copyright 2016 The Kubernetes Authors All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+This soak test runs inside a cluster for days at a time, performing a low
+and steady rate of pod and service churn while continuously recording
+API request latency, DNS resolution success and service reachability.
+Snapshots of the accumulated measurements are flushed to disk periodically
+so that slow leaks and gradual degradations, which short burst tests miss,
+show up as trends across snapshots.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	client "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/util/intstr"
+)
+
+var (
+	churnPeriod    = flag.Duration("churn_period", 30*time.Second, "Time between pod/service create+delete cycles")
+	snapshotPeriod = flag.Duration("snapshot_period", 10*time.Minute, "Time between snapshots written to disk")
+	reportDir      = flag.String("report_dir", "/var/log/sli-recorder", "Directory into which snapshots are written")
+	upTo           = flag.Int("up_to", -1, "Number of churn iterations or -1 for no limit")
+)
+
+const (
+	podStartTimeout        = 5 * time.Minute
+	namespaceDeleteTimeout = 5 * time.Minute
+	probeTimeout           = 10 * time.Second
+	pollInterval           = 2 * time.Second
+)
+
+// snapshot is the unit flushed to disk. Counters are cumulative since the
+// start of the run so that any pair of snapshots can be diffed.
+type snapshot struct {
+	Time               time.Time       `json:"time"`
+	Iterations         int             `json:"iterations"`
+	APIRequests        int             `json:"apiRequests"`
+	APIErrors          int             `json:"apiErrors"`
+	APILatencies       []time.Duration `json:"apiLatencies"`
+	DNSLookups         int             `json:"dnsLookups"`
+	DNSFailures        int             `json:"dnsFailures"`
+	ServiceProbes      int             `json:"serviceProbes"`
+	ServiceProbeErrors int             `json:"serviceProbeErrors"`
+	PodStartTimeouts   int             `json:"podStartTimeouts"`
+}
+
+// recorder accumulates SLI measurements and knows how to flush them.
+type recorder struct {
+	snap snapshot
+}
+
+// observeAPI records the latency and outcome of a single API request.
+func (r *recorder) observeAPI(latency time.Duration, err error) {
+	r.snap.APIRequests++
+	r.snap.APILatencies = append(r.snap.APILatencies, latency)
+	if err != nil {
+		r.snap.APIErrors++
+	}
+}
+
+func (r *recorder) flush() {
+	r.snap.Time = time.Now()
+	data, err := json.Marshal(r.snap)
+	if err != nil {
+		glog.Errorf("Failed to marshal snapshot: %v", err)
+		return
+	}
+	name := filepath.Join(*reportDir, fmt.Sprintf("sli-%s.json", r.snap.Time.Format("20060102-150405")))
+	if err := ioutil.WriteFile(name, data, 0644); err != nil {
+		glog.Errorf("Failed to write snapshot %s: %v", name, err)
+		return
+	}
+	glog.Infof("Wrote snapshot %s after %d iterations", name, r.snap.Iterations)
+	// Latencies are reported per snapshot rather than cumulatively to keep
+	// snapshot files from growing without bound over a multi-day run.
+	r.snap.APILatencies = nil
+}
+
+func main() {
+	flag.Parse()
+
+	glog.Infof("Starting sli_recorder soak with churnPeriod=%v snapshotPeriod=%v upTo=%d",
+		*churnPeriod, *snapshotPeriod, *upTo)
+
+	if err := os.MkdirAll(*reportDir, 0755); err != nil {
+		glog.Fatalf("Failed to create report directory %s: %v", *reportDir, err)
+	}
+
+	c, err := client.NewInCluster()
+	if err != nil {
+		glog.Fatalf("Failed to make client: %v", err)
+	}
+
+	// Create a uniquely named namespace for the churn objects.
+	got, err := c.Namespaces().Create(&api.Namespace{ObjectMeta: api.ObjectMeta{GenerateName: "sli-recorder-"}})
+	if err != nil {
+		glog.Fatalf("Failed to create namespace: %v", err)
+	}
+	ns := got.Name
+	defer func(ns string) {
+		if err := c.Namespaces().Delete(ns); err != nil {
+			glog.Warningf("Failed to delete namespace %s: %v", ns, err)
+		} else {
+			// wait until the namespace disappears
+			for i := 0; i < int(namespaceDeleteTimeout/time.Second); i++ {
+				if _, err := c.Namespaces().Get(ns); err != nil {
+					if errors.IsNotFound(err) {
+						return
+					}
+				}
+				time.Sleep(time.Second)
+			}
+		}
+	}(ns)
+	glog.Infof("Created namespace %s", ns)
+
+	rec := &recorder{}
+	lastSnapshot := time.Now()
+
+	for i := 0; *upTo < 0 || i < *upTo; i++ {
+		rec.snap.Iterations++
+		name := fmt.Sprintf("sli-recorder-%d", i)
+		iterate(c, ns, name, rec)
+
+		if time.Since(lastSnapshot) >= *snapshotPeriod {
+			rec.flush()
+			lastSnapshot = time.Now()
+		}
+		time.Sleep(*churnPeriod)
+	}
+	rec.flush()
+}
+
+// iterate performs one churn cycle: create a serve_hostname pod and a
+// service selecting it, wait for the pod to run, probe the service via DNS
+// and HTTP, then delete both.
+func iterate(c *client.Client, ns, name string, rec *recorder) {
+	t := time.Now()
+	_, err := c.Pods(ns).Create(&api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"name": name},
+		},
+		Spec: api.PodSpec{
+			Containers: []api.Container{
+				{
+					Name:  "serve-hostname",
+					Image: "gcr.io/google_containers/serve_hostname:1.1",
+					Ports: []api.ContainerPort{{ContainerPort: 9376}},
+				},
+			},
+		},
+	})
+	rec.observeAPI(time.Since(t), err)
+	if err != nil {
+		glog.Warningf("Failed to create pod %s/%s: %v", ns, name, err)
+		return
+	}
+	defer func() {
+		t := time.Now()
+		err := c.Pods(ns).Delete(name, nil)
+		rec.observeAPI(time.Since(t), err)
+		if err != nil {
+			glog.Warningf("Failed to delete pod %s/%s: %v", ns, name, err)
+		}
+	}()
+
+	t = time.Now()
+	_, err = c.Services(ns).Create(&api.Service{
+		ObjectMeta: api.ObjectMeta{Name: name},
+		Spec: api.ServiceSpec{
+			Ports: []api.ServicePort{{
+				Protocol:   "TCP",
+				Port:       9376,
+				TargetPort: intstr.FromInt(9376),
+			}},
+			Selector: map[string]string{"name": name},
+		},
+	})
+	rec.observeAPI(time.Since(t), err)
+	if err != nil {
+		glog.Warningf("Failed to create service %s/%s: %v", ns, name, err)
+		return
+	}
+	defer func() {
+		t := time.Now()
+		err := c.Services(ns).Delete(name)
+		rec.observeAPI(time.Since(t), err)
+		if err != nil {
+			glog.Warningf("Failed to delete service %s/%s: %v", ns, name, err)
+		}
+	}()
+
+	// Wait for the pod to run before probing.
+	running := false
+	for start := time.Now(); time.Since(start) < podStartTimeout; time.Sleep(pollInterval) {
+		t := time.Now()
+		pod, err := c.Pods(ns).Get(name)
+		rec.observeAPI(time.Since(t), err)
+		if err != nil {
+			continue
+		}
+		if pod.Status.Phase == api.PodRunning {
+			running = true
+			break
+		}
+	}
+	if !running {
+		rec.snap.PodStartTimeouts++
+		glog.Warningf("Pod %s/%s did not reach running before timeout", ns, name)
+		return
+	}
+
+	// DNS resolution of the service name. We run in-cluster so the
+	// cluster DNS search path applies.
+	host := fmt.Sprintf("%s.%s", name, ns)
+	rec.snap.DNSLookups++
+	addrs, err := net.LookupHost(host)
+	if err != nil || len(addrs) == 0 {
+		rec.snap.DNSFailures++
+		glog.Warningf("DNS lookup of %s failed: %v", host, err)
+		return
+	}
+
+	// Service reachability via the virtual IP.
+	rec.snap.ServiceProbes++
+	httpClient := http.Client{Timeout: probeTimeout}
+	resp, err := httpClient.Get("http://" + net.JoinHostPort(addrs[0], "9376"))
+	if err != nil {
+		rec.snap.ServiceProbeErrors++
+		glog.Warningf("Failed to reach service %s/%s: %v", ns, name, err)
+		return
+	}
+	defer resp.Body.Close()
+	if _, err := ioutil.ReadAll(resp.Body); err != nil {
+		rec.snap.ServiceProbeErrors++
+		glog.Warningf("Failed to read from service %s/%s: %v", ns, name, err)
+	}
+}